@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"codeberg.org/anaseto/gruid"
+)
+
+// MaxAllies is the maximum number of allies the player can have at once.
+const MaxAllies = 2
+
+// AllyFollowDistance is the distance from the player at which an ally tries
+// to come back, and within which it follows the player through stairs.
+const AllyFollowDistance = 3
+
+// AllyBand is the band of allies: they do not belong to any band of the
+// level.
+const AllyBand = -1
+
+// Allies returns the allies of the player on the current level.
+func (g *game) Allies() []*monster {
+	allies := []*monster{}
+	for _, mons := range g.Monsters {
+		if mons.Exists() && mons.Ally {
+			allies = append(allies, mons)
+		}
+	}
+	return allies
+}
+
+// Charm turns a monster into an ally of the player.
+func (m *monster) Charm(g *game) {
+	m.Ally = true
+	m.Band = AllyBand
+	m.State = Hunting
+	m.Behaviour = BehaveWander
+	m.Path = nil
+	m.Target = g.Player.P
+	m.Obstructing = false
+}
+
+// Charmable returns an error if the monster cannot become an ally.
+func (m *monster) Charmable(g *game) error {
+	switch {
+	case m.Ally:
+		return fmt.Errorf("%s is already your ally.", m.Kind.Definite(true))
+	case m.Kind == MonsMarevorHelith:
+		return errors.New("Marevor is not impressed.")
+	case len(g.Allies()) >= MaxAllies:
+		return fmt.Errorf("You cannot have more than %d allies.", MaxAllies)
+	}
+	return nil
+}
+
+func (g *game) EvokeRodCharming(ev event) error {
+	if err := g.ui.ChooseTarget(&chooser{}); err != nil {
+		return err
+	}
+	mons := g.MonsterAt(g.Player.Target)
+	// mons not nil (check done in the targeter)
+	if err := mons.Charmable(g); err != nil {
+		return err
+	}
+	mons.Charm(g)
+	g.PrintfStyled("%s is now your ally.", logSpecial, mons.Kind.Definite(true))
+	return nil
+}
+
+// NearestFoe returns the nearest awake hostile monster that the ally can
+// fight, or nil.
+func (m *monster) NearestFoe(g *game) *monster {
+	var foe *monster
+	best := 0
+	for _, mons := range g.Monsters {
+		if !mons.Exists() || mons.Ally || mons.State == Resting || !g.Player.LOS[mons.P] {
+			continue
+		}
+		d := Distance(m.P, mons.P)
+		if foe == nil || d < best {
+			foe = mons
+			best = d
+		}
+	}
+	return foe
+}
+
+// HandleAllyTurn handles the turn of an allied monster: it fights visible
+// foes, and otherwise follows the player.
+func (m *monster) HandleAllyTurn(g *game, ev event) {
+	movedelay := m.MovementDelay()
+	adelay := m.AttackDelay()
+	if m.State == Resting {
+		if m.Status(StatusExhausted) {
+			ev.Renew(g, movedelay)
+			return
+		}
+		m.State = Hunting
+	}
+	if m.Infight(g, ev) {
+		return
+	}
+	foe := m.NearestFoe(g)
+	if foe != nil && Distance(m.P, foe.P) == 1 && m.Kind != MonsSatowalgaPlant && m.Kind != MonsMarevorHelith {
+		m.HitMonster(g, foe, ev)
+		ev.Renew(g, adelay)
+		return
+	}
+	if m.Status(StatusLignification) || m.Kind == MonsSatowalgaPlant {
+		ev.Renew(g, 10) // wait
+		return
+	}
+	switch {
+	case Distance(m.P, g.Player.P) > AllyFollowDistance:
+		m.Target = g.Player.P
+	case foe != nil:
+		m.Target = foe.P
+	default:
+		ev.Renew(g, movedelay)
+		return
+	}
+	m.Path = m.APath(g, m.P, m.Target)
+	if len(m.Path) < 2 {
+		ev.Renew(g, movedelay)
+		return
+	}
+	target := m.Path[1]
+	if target == g.Player.P || g.MonsterAt(target).Exists() || g.Dungeon.Cell(target).T == WallCell || m.DoorBlocks(g, target) {
+		ev.Renew(g, movedelay)
+		return
+	}
+	m.Path = m.Path[1:]
+	if m.MoveTo(g, target, ev) || m.Exists() {
+		ev.Renew(g, movedelay)
+	}
+}
+
+// AdjacentAlly returns an ally adjacent to the monster, or nil.
+func (m *monster) AdjacentAlly(g *game) *monster {
+	for _, p := range g.Dungeon.FreeNeighbors(m.P) {
+		mons := g.MonsterAt(p)
+		if mons.Exists() && mons.Ally {
+			return mons
+		}
+	}
+	return nil
+}
+
+// TakeAllies removes from the level the allies close enough to the player
+// to follow through stairs, and returns them.
+func (g *game) TakeAllies() []monster {
+	allies := []monster{}
+	for _, mons := range g.Allies() {
+		if Distance(mons.P, g.Player.P) > AllyFollowDistance || mons.Status(StatusLignification) {
+			continue
+		}
+		allies = append(allies, *mons)
+		g.MonstersPosCache[idx(mons.P)] = 0
+		mons.HP = 0
+	}
+	return allies
+}
+
+// PlaceAllies places allies that followed the player on the new level, as
+// close to the player as possible.
+func (g *game) PlaceAllies(allies []monster) {
+	if len(allies) == 0 {
+		return
+	}
+	dij := &normalPath{game: g}
+	nodes := g.PR.BreadthFirstMap(dij, []gruid.Point{g.Player.P}, 2*AllyFollowDistance)
+	for i := range allies {
+		m := &allies[i]
+		p := InvalidPos
+		best := 0
+		for _, n := range nodes {
+			if n.P == g.Player.P || g.MonsterAt(n.P).Exists() || g.Dungeon.Cell(n.P).T != FreeCell {
+				continue
+			}
+			if !valid(p) || n.Cost < best {
+				p = n.P
+				best = n.Cost
+			}
+		}
+		if !valid(p) {
+			g.Printf("%s could not follow you.", m.Kind.Definite(true))
+			continue
+		}
+		m.Index = len(g.Monsters)
+		// status end events were lost with the level
+		m.Statuses = [NumStatuses]int{}
+		m.Path = nil
+		m.Target = g.Player.P
+		m.P = p
+		g.Monsters = append(g.Monsters, m)
+		g.MonstersPosCache[idx(p)] = m.Index + 1
+		g.PushEvent(&monsterEvent{ERank: g.Turn + RandInt(10), EAction: MonsterTurn, NMons: m.Index})
+		g.Printf("%s follows you.", m.Kind.Definite(true))
+	}
+}
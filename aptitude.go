@@ -43,9 +43,9 @@ func (ap aptitude) String() string {
 	case AptMagic:
 		text = "You have big magic reserves."
 	case AptStealthyLOS:
-		text = "The shadows follow you. (reduced LOS)"
+		text = "The shadows follow you. (reduced LOS, better vision and stealth in darkness)"
 	case AptConfusingGas:
-		text = "You occasionally release some confusing gas when hurt."
+		text = "You occasionally release some confusing gas when hurt. You are not affected by confusing gas."
 	case AptSmoke:
 		text = "You occasionally get energetic and emit smoke clouds when hurt."
 	case AptLignification:
@@ -56,6 +56,40 @@ func (ap aptitude) String() string {
 	return text
 }
 
+func (ap aptitude) Description() (text string) {
+	switch ap {
+	case AptObstruction:
+		text = "When you are heavily wounded, a monster hitting you is sometimes blown away, and a temporal wall emerges at its place."
+	case AptAgile:
+		text = "Your evasion is increased, so that monsters miss you more often."
+	case AptFast:
+		text = "You move faster than normal. This does not affect other actions."
+	case AptHealthy:
+		text = "Your maximum health is increased by 10."
+	case AptStealthyMovement:
+		text = "Monsters notice you less often, and lose track of you more easily."
+	case AptScales:
+		text = "Your armour is increased by 2."
+	case AptHear:
+		text = "You hear monster noises from further away, and locate them more precisely."
+	case AptStrong:
+		text = "You deal more damage with your weapon and with darts."
+	case AptMagic:
+		text = "Your maximum magic is increased by 2."
+	case AptStealthyLOS:
+		text = "Your line of sight is reduced by 2, but you see further in darkness, and monsters notice you less often there."
+	case AptConfusingGas:
+		text = "When you are heavily wounded, you sometimes release some confusing gas against a monster hitting you. You are not affected by confusing gas."
+	case AptSmoke:
+		text = "When you are heavily wounded, you sometimes get energetic and emit smoke clouds, becoming swift for a while."
+	case AptLignification:
+		text = "When you are heavily wounded, a monster hitting you is sometimes lignified."
+	case AptTeleport:
+		text = "When you are heavily wounded, a monster hitting you is sometimes teleported away."
+	}
+	return text
+}
+
 func (g *game) RandomApt() (aptitude, bool) {
 	count := 0
 	var apt aptitude
@@ -74,6 +108,73 @@ func (g *game) RandomApt() (aptitude, bool) {
 	return apt, false
 }
 
+// OfferedAptitudes returns up to n distinct random aptitudes that the player
+// does not have yet.
+func (g *game) OfferedAptitudes(n int) []aptitude {
+	apts := []aptitude{}
+	for i := 0; i < NumApts; i++ {
+		if !g.Player.Aptitudes[aptitude(i)] {
+			apts = append(apts, aptitude(i))
+		}
+	}
+	for i := len(apts) - 1; i > 0; i-- {
+		j := RandInt(i + 1)
+		apts[i], apts[j] = apts[j], apts[i]
+	}
+	if len(apts) > n {
+		apts = apts[:n]
+	}
+	return apts
+}
+
+// GainAptitude gives a new aptitude to the player: a random one if aptitudes
+// are random, or otherwise a choice among a few offered ones, queued to be
+// made at the start of a player turn.
+func (g *game) GainAptitude() {
+	if g.Opts.RandomAptitudes {
+		apt, ok := g.RandomApt()
+		if ok {
+			g.ApplyAptitude(apt)
+		}
+		return
+	}
+	apts := g.OfferedAptitudes(3)
+	if len(apts) > 0 {
+		g.AptitudeChoices = append(g.AptitudeChoices, apts)
+	}
+}
+
+// PendingAptitudes returns the aptitudes offered for the oldest pending
+// choice, if any. Offered aptitudes gained in the meantime are dropped, and
+// new ones are offered if none remain.
+func (g *game) PendingAptitudes() []aptitude {
+	for len(g.AptitudeChoices) > 0 {
+		apts := []aptitude{}
+		for _, apt := range g.AptitudeChoices[0] {
+			if !g.Player.Aptitudes[apt] {
+				apts = append(apts, apt)
+			}
+		}
+		if len(apts) == 0 {
+			apts = g.OfferedAptitudes(3)
+		}
+		if len(apts) > 0 {
+			g.AptitudeChoices[0] = apts
+			return apts
+		}
+		g.AptitudeChoices = g.AptitudeChoices[1:]
+	}
+	return nil
+}
+
+// ApplyChosenAptitude applies the aptitude chosen for the oldest pending
+// choice.
+func (g *game) ApplyChosenAptitude(apt aptitude) {
+	g.AptitudeChoices = g.AptitudeChoices[1:]
+	g.ApplyAptitude(apt)
+	g.StoryPrintf("Gained aptitude: %s", apt)
+}
+
 func (g *game) ApplyAptitude(ap aptitude) {
 	if g.Player.Aptitudes[ap] {
 		// should not happen
@@ -41,8 +41,12 @@ func (g *game) AllExplored() bool {
 				continue
 			}
 		}
+		if g.Vault[p] && !c.Explored {
+			// vaults are optional
+			continue
+		}
 		_, okc := g.Collectables[p]
-		if !c.Explored || g.Simellas[p] > 0 || okc {
+		if !c.Explored || g.Simellas[p] > 0 || okc || g.Keys[p] {
 			return false
 		} else if _, ok := g.Rods[p]; ok {
 			return false
@@ -65,7 +69,7 @@ func (g *game) AutoexploreSources() []gruid.Point {
 			continue
 		}
 		_, okc := g.Collectables[p]
-		if !c.Explored || g.Simellas[p] > 0 || okc {
+		if !c.Explored || g.Simellas[p] > 0 || okc || g.Keys[p] {
 			sources = append(sources, idx2Point(i))
 		} else if _, ok := g.Rods[p]; ok {
 			sources = append(sources, idx2Point(i))
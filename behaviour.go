@@ -0,0 +1,181 @@
+package main
+
+import (
+	"sort"
+
+	"codeberg.org/anaseto/gruid"
+)
+
+// monsterBehaviour describes what a monster does when it is not hunting the
+// player.
+type monsterBehaviour int
+
+const (
+	BehaveWander monsterBehaviour = iota // wander to random places
+	BehavePatrol                         // patrol between points of interest
+	BehaveGuard                          // watch over a place or an item
+	BehaveLair                           // sleep in a lair
+)
+
+func (b monsterBehaviour) String() (text string) {
+	switch b {
+	case BehavePatrol:
+		text = "This one is patrolling."
+	case BehaveGuard:
+		text = "This one is guarding a place."
+	case BehaveLair:
+		text = "This one has its lair around."
+	}
+	return text
+}
+
+// PointsOfInterest returns, in a stable order, the places a patrol can go
+// through and a guard can watch over.
+func (g *game) PointsOfInterest() []gruid.Point {
+	ps := []gruid.Point{}
+	for p := range g.Stairs {
+		ps = append(ps, p)
+	}
+	for p := range g.MagicalStones {
+		ps = append(ps, p)
+	}
+	for p := range g.Collectables {
+		ps = append(ps, p)
+	}
+	for p := range g.Equipables {
+		ps = append(ps, p)
+	}
+	for p := range g.Rods {
+		ps = append(ps, p)
+	}
+	for p := range g.Simellas {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].Y < ps[j].Y || ps[i].Y == ps[j].Y && ps[i].X < ps[j].X
+	})
+	return ps
+}
+
+// GenMonsterBehaviours assigns behaviours to the monsters of the level, from
+// band data or randomly. It should be called once all the items have been
+// placed.
+func (g *game) GenMonsterBehaviours() {
+	pois := g.PointsOfInterest()
+	if len(pois) == 0 {
+		return
+	}
+	for band := range g.Bands {
+		mates := []*monster{}
+		for _, mons := range g.Monsters {
+			if mons.Band == band {
+				mates = append(mates, mons)
+			}
+		}
+		if len(mates) == 0 {
+			continue
+		}
+		leader := mates[0]
+		switch leader.Kind {
+		case MonsSatowalgaPlant, MonsMarevorHelith:
+			continue
+		}
+		bh := g.BandData[g.Bands[band]].Behaviour
+		if bh == BehaveWander {
+			switch RandInt(8) {
+			case 0:
+				bh = BehavePatrol
+			case 1:
+				bh = BehaveGuard
+			}
+		}
+		var route []gruid.Point
+		post := leader.P
+		switch bh {
+		case BehavePatrol:
+			route = []gruid.Point{leader.P}
+			for i := 0; i < 2; i++ {
+				route = append(route, pois[RandInt(len(pois))])
+			}
+		case BehaveGuard:
+			post = g.NearestPointOfInterest(pois, leader.P)
+		}
+		for _, mons := range mates {
+			mons.Behaviour = bh
+			mons.Post = post
+			mons.Route = route
+			switch bh {
+			case BehavePatrol:
+				mons.State = Wandering
+				mons.Target = route[1]
+				mons.RouteIndex = 1
+			case BehaveGuard:
+				mons.State = Wandering
+				mons.Target = post
+			}
+		}
+	}
+}
+
+// NearestPointOfInterest returns the point of interest nearest to p, if it
+// is close enough, or p otherwise.
+func (g *game) NearestPointOfInterest(pois []gruid.Point, p gruid.Point) gruid.Point {
+	const maxDistance = 12
+	best := p
+	bestd := maxDistance + 1
+	for _, q := range pois {
+		if d := Distance(p, q); d < bestd {
+			best = q
+			bestd = d
+		}
+	}
+	return best
+}
+
+// NextWanderTarget chooses a new target for a wandering monster that
+// reached its destination. It returns false for monsters without a
+// particular behaviour.
+func (m *monster) NextWanderTarget(g *game) bool {
+	switch m.Behaviour {
+	case BehavePatrol:
+		if len(m.Route) == 0 {
+			return false
+		}
+		m.RouteIndex = (m.RouteIndex + 1) % len(m.Route)
+		m.Target = m.Route[m.RouteIndex]
+	case BehaveGuard:
+		if Distance(m.P, m.Post) <= 2 {
+			// close enough to watch over the post
+			m.Target = m.P
+		} else {
+			m.Target = m.Post
+		}
+	case BehaveLair:
+		m.Target = m.Post
+		if Distance(m.P, m.Post) <= 1 {
+			m.State = Resting
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// ReturnToPost makes a monster that lost track of the player go back to its
+// post, lair or patrol route. It returns false for monsters without a
+// particular behaviour.
+func (m *monster) ReturnToPost(g *game) bool {
+	switch m.Behaviour {
+	case BehavePatrol:
+		if len(m.Route) == 0 {
+			return false
+		}
+		m.Target = g.NearestPointOfInterest(m.Route, m.P)
+	case BehaveGuard, BehaveLair:
+		m.Target = m.Post
+	default:
+		return false
+	}
+	m.State = Wandering
+	return true
+}
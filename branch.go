@@ -0,0 +1,194 @@
+package main
+
+import "fmt"
+
+// branch identifies a side branch of the dungeon. Branch levels are entered
+// from special stairs in a main dungeon level and lead back there.
+type branch int
+
+const (
+	NoBranch branch = iota
+	BranchWarren
+	BranchOvergrownCaves
+)
+
+type branchData struct {
+	name     string
+	minDepth int // minimum depth of the entry level
+	maxDepth int // maximum depth of the entry level
+	length   int // number of levels
+	layouts  []dungen
+	bands    []monsterBandData
+}
+
+var BranchData map[branch]*branchData
+
+func (b branch) String() string {
+	return BranchData[b].name
+}
+
+func (b branch) Length() int {
+	return BranchData[b].length
+}
+
+func init() {
+	BranchData = map[branch]*branchData{
+		NoBranch: {name: "Underground"},
+		BranchWarren: {
+			name:     "Goblin Warren",
+			minDepth: 2,
+			maxDepth: 5,
+			length:   2,
+			layouts:  []dungen{GenRoomMap, GenBSPMap, GenRuinsMap},
+			bands: []monsterBandData{
+				{Monster: MonsGoblin, Rarity: 5},
+				{Monster: MonsHound, Rarity: 15},
+				{Distribution: map[monsterKind]monsInterval{
+					MonsGoblin: {2, 4},
+				}, Rarity: 4, Band: true, Tactics: TacticSurround | TacticAmbush, Behaviour: BehavePatrol},
+				{Distribution: map[monsterKind]monsInterval{
+					MonsGoblin: {1, 2}, MonsHound: {1, 2},
+				}, Rarity: 6, Band: true, Tactics: TacticSurround},
+				{Distribution: map[monsterKind]monsInterval{
+					MonsGoblin: {2, 3}, MonsGoblinWarrior: {1, 1},
+				}, Rarity: 8, MinDepth: 4, Band: true, Tactics: TacticSurround | TacticRangedBehind | TacticAmbush},
+				{Distribution: map[monsterKind]monsInterval{
+					MonsGoblinWarrior: {2, 2}, MonsHound: {1, 1},
+				}, Rarity: 12, MinDepth: 6, Band: true, Tactics: TacticRangedBehind | TacticAmbush, Behaviour: BehaveGuard},
+			},
+		},
+		BranchOvergrownCaves: {
+			name:     "Overgrown Caves",
+			minDepth: 4,
+			maxDepth: 7,
+			length:   2,
+			layouts:  []dungen{GenCaveMap, GenCellularAutomataCaveMap, GenCaveMapTree},
+			bands: []monsterBandData{
+				{Monster: MonsBlinkingFrog, Rarity: 8},
+				{Monster: MonsSatowalgaPlant, Rarity: 12},
+				{Monster: MonsAcidMound, Rarity: 15},
+				{Distribution: map[monsterKind]monsInterval{
+					MonsBlinkingFrog: {2, 3},
+				}, Rarity: 8, Band: true},
+				{Distribution: map[monsterKind]monsInterval{
+					MonsMadNixe: {1, 1}, MonsBlinkingFrog: {1, 2},
+				}, Rarity: 10, Band: true},
+				{Distribution: map[monsterKind]monsInterval{
+					MonsTreeMushroom: {1, 1}, MonsSatowalgaPlant: {1, 1},
+				}, Rarity: 12, MinDepth: 6, Band: true},
+				{Distribution: map[monsterKind]monsInterval{
+					MonsBrizzia: {1, 1}, MonsAcidMound: {1, 1},
+				}, Rarity: 10, Band: true},
+			},
+		},
+	}
+	for _, bd := range BranchData {
+		for i := range bd.bands {
+			bd.bands[i].MaxDepth = MaxDepth
+		}
+	}
+}
+
+// levelID identifies a level: a depth in the main dungeon or in a branch.
+type levelID struct {
+	Branch branch
+	Depth  int
+}
+
+func (g *game) LevelID() levelID {
+	if g.Branch != NoBranch {
+		return levelID{Branch: g.Branch, Depth: g.BranchDepth}
+	}
+	return levelID{Branch: NoBranch, Depth: g.Depth}
+}
+
+// DangerDepth returns the depth used for monster generation. Branch levels
+// get harder the deeper they are in the branch.
+func (g *game) DangerDepth() int {
+	return g.Depth + g.BranchDepth
+}
+
+func (g *game) InitBranches() {
+	g.Opts.Branches = map[int]branch{}
+	for _, b := range []branch{BranchWarren, BranchOvergrownCaves} {
+		if RandInt(3) == 0 {
+			continue
+		}
+		bd := BranchData[b]
+		depth := bd.minDepth + RandInt(bd.maxDepth-bd.minDepth+1)
+		if _, ok := g.Opts.Branches[depth]; ok {
+			depth++
+		}
+		g.Opts.Branches[depth] = b
+	}
+}
+
+// BranchEntry returns the branch entered from the current level, if any.
+func (g *game) BranchEntry() (branch, bool) {
+	if g.Branch != NoBranch {
+		return NoBranch, false
+	}
+	b, ok := g.Opts.Branches[g.Depth]
+	return b, ok
+}
+
+func (g *game) GenBranchDungeon() {
+	layouts := BranchData[g.Branch].layouts
+	layouts[RandInt(len(layouts))].Use(g)
+}
+
+// GenBranchReward places the reward found at the end of a branch.
+func (g *game) GenBranchReward() {
+	switch g.Branch {
+	case BranchWarren:
+		if g.ArmourAvailable() {
+			g.GenArmour()
+			break
+		}
+		fallthrough
+	case BranchOvergrownCaves:
+		if g.RodAvailable() {
+			g.GenerateRod()
+			break
+		}
+		for i := 0; i < 2; i++ {
+			g.GenCollectable()
+			g.CollectableScore--
+		}
+	}
+}
+
+// ArmourAvailable reports whether there are still armours that can be
+// generated.
+func (g *game) ArmourAvailable() bool {
+	n := 0
+	for eq := range g.GeneratedEquipables {
+		if _, ok := eq.(armour); ok {
+			n++
+		}
+	}
+	return n < int(HarmonistRobe) // all but the robe
+}
+
+// RodAvailable reports whether there are still rods that can be generated.
+func (g *game) RodAvailable() bool {
+	for i := 0; i < NumRods; i++ {
+		r := rod(i)
+		if _, ok := g.Player.Rods[r]; !ok && !g.GeneratedRods[r] {
+			return true
+		}
+	}
+	return false
+}
+
+// BranchEnd reports whether the current level is the last of a branch.
+func (g *game) BranchEnd() bool {
+	return g.Branch != NoBranch && g.BranchDepth == g.Branch.Length()
+}
+
+func (g *game) BranchMessage() string {
+	if g.BranchEnd() {
+		return fmt.Sprintf("You reached the end of the %s. Something valuable might be around.", g.Branch)
+	}
+	return fmt.Sprintf("You enter the %s.", g.Branch)
+}
@@ -9,6 +9,11 @@ func (g *game) Absorb(armor int) int {
 	for i := 0; i <= 2; i++ {
 		absorb += RandInt(armor + 1)
 	}
+	return AbsorbSum(absorb)
+}
+
+// AbsorbSum returns the absorbed damage for a sum of three armor rolls.
+func AbsorbSum(absorb int) int {
 	q := absorb / 3
 	r := absorb % 3
 	if r == 2 {
@@ -18,20 +23,71 @@ func (g *game) Absorb(armor int) int {
 }
 
 func (g *game) HitDamage(dt dmgType, base int, armor int) (attack int, clang bool) {
-	min := base / 2
-	attack = min + RandInt(base-min+1)
-	absorb := g.Absorb(armor)
+	dr := g.DamageRoll(dt, base, armor)
+	return dr.Damage(), dr.Clang
+}
+
+// DamageRoll rolls the damage of an attack against some armor.
+func (g *game) DamageRoll(dt dmgType, base int, armor int) damageRoll {
+	dr := damageRoll{Base: base, Armor: armor}
+	min := MinAttack(base)
+	dr.Attack = min + RandInt(base-min+1)
+	dr.Absorb = TypeAbsorb(dt, g.Absorb(armor))
+	if dr.Absorb > 0 && dr.Absorb >= 2*armor/3 && RandInt(2) == 0 {
+		dr.Clang = true
+	}
+	return dr
+}
+
+// MinAttack returns the minimal attack roll for a given base attack.
+func MinAttack(base int) int {
+	return base / 2
+}
+
+// TypeAbsorb returns the absorbed damage for a damage type, given the
+// absorption of physical damage.
+func TypeAbsorb(dt dmgType, absorb int) int {
 	if dt == DmgMagical {
 		absorb = 2 * absorb / 3
 	}
-	attack -= absorb
-	if absorb > 0 && absorb >= 2*armor/3 && RandInt(2) == 0 {
-		clang = true
+	return absorb
+}
+
+// DamageDistribution returns the probability distribution of the damage
+// rolled by DamageRoll: the i-th element is the probability of inflicting i
+// damage.
+func DamageDistribution(dt dmgType, base, armor int) []float64 {
+	// distribution of the sum of the three armor rolls in Absorb
+	sums := []int{1}
+	for i := 0; i <= 2; i++ {
+		next := make([]int, len(sums)+armor)
+		for s, c := range sums {
+			for r := 0; r <= armor; r++ {
+				next[s+r] += c
+			}
+		}
+		sums = next
+	}
+	min := MinAttack(base)
+	dist := make([]float64, Max(base, 0)+1)
+	count := (base - min + 1) * (armor + 1) * (armor + 1) * (armor + 1)
+	for attack := min; attack <= base; attack++ {
+		for s, c := range sums {
+			dmg := AbsorbDamage(attack, TypeAbsorb(dt, AbsorbSum(s)))
+			dist[dmg] += float64(c) / float64(count)
+		}
 	}
+	return dist
+}
+
+// AbsorbDamage returns the damage inflicted by an attack roll once armor
+// absorbed some of it.
+func AbsorbDamage(attack, absorb int) int {
+	attack -= absorb
 	if attack < 0 {
 		attack = 0
 	}
-	return attack, clang
+	return attack
 }
 
 func (m *monster) InflictDamage(g *game, damage, max int) {
@@ -51,6 +107,7 @@ func (m *monster) InflictDamage(g *game, damage, max int) {
 	if !ok {
 		return
 	}
+	g.CombatPrintf("%s triggered under you", stn)
 	switch stn {
 	case TeleStone:
 		g.UseStone(g.Player.P)
@@ -114,16 +171,21 @@ func (g *game) MakeMonstersAware() {
 }
 
 func (g *game) MakeNoise(noise int, at gruid.Point) {
-	dij := &normalPath{game: g}
-	g.PR.BreadthFirstMap(dij, []gruid.Point{at}, noise)
+	dij := &soundPath{game: g}
+	g.PR.DijkstraMap(dij, []gruid.Point{at}, noise)
+	if at != g.Player.P && !g.Player.LOS[at] {
+		if v := noise - g.PR.DijkstraMapAt(g.Player.P); v > 0 {
+			g.HearNoise(at, v)
+		}
+	}
 	for _, m := range g.Monsters {
 		if !m.Exists() {
 			continue
 		}
-		if m.State == Hunting {
+		if m.State == Hunting || m.State == Fleeing {
 			continue
 		}
-		c := g.PR.BreadthFirstMapAt(m.P)
+		c := g.PR.DijkstraMapAt(m.P)
 		if c > noise {
 			continue
 		}
@@ -138,7 +200,7 @@ func (g *game) MakeNoise(noise int, at gruid.Point) {
 		if m.State == Resting {
 			v /= 2
 		}
-		if m.Status(MonsExhausted) {
+		if m.Status(StatusExhausted) {
 			v = 2 * v / 3
 		}
 		if v > r {
@@ -147,6 +209,7 @@ func (g *game) MakeNoise(noise int, at gruid.Point) {
 			} else {
 				m.Target = at
 				m.State = Wandering
+				g.AddNoiseTarget(at)
 			}
 			m.GatherBand(g)
 		}
@@ -168,8 +231,8 @@ func (g *game) InOpenMons(mons *monster) bool {
 
 func (g *game) AttackMonster(mons *monster, ev event) {
 	switch {
-	case g.Player.HasStatus(StatusSwap) && !g.Player.HasStatus(StatusLignification) && !mons.Status(MonsLignified):
-		g.SwapWithMonster(mons)
+	case g.Player.HasStatus(StatusSwap) && !g.Player.HasStatus(StatusLignification) && !mons.Status(StatusLignification):
+		g.SwapWithMonster(mons, ev)
 	case g.Player.Weapon == Frundis:
 		if !g.HitMonster(DmgPhysical, g.Player.Attack(), mons, ev) {
 			break
@@ -178,6 +241,20 @@ func (g *game) AttackMonster(mons *monster, ev event) {
 			mons.EnterConfusion(g, ev)
 			g.PrintfStyled("Frundis glows… %s appears confused.", logPlayerHit, mons.Kind.Definite(false))
 		}
+	case g.Player.Weapon == ViperDagger:
+		if !g.HitMonster(DmgPhysical, g.Player.Attack(), mons, ev) || !mons.Exists() || !mons.Kind.Living() {
+			break
+		}
+		mons.PutStatus(g, StatusPoison, ev)
+		g.Printf("%s is poisoned.", mons.Kind.Definite(true))
+	case g.Player.Weapon == SerratedSabre:
+		if !g.HitMonster(DmgPhysical, g.Player.Attack(), mons, ev) || !mons.Exists() || !mons.Kind.Living() {
+			break
+		}
+		if RandInt(2) == 0 {
+			mons.PutStatus(g, StatusBleeding, ev)
+			g.Printf("%s starts bleeding.", mons.Kind.Definite(true))
+		}
 	case g.Player.Weapon.Cleave():
 		var neighbors []gruid.Point
 		if g.Player.HasStatus(StatusConfusion) {
@@ -187,7 +264,7 @@ func (g *game) AttackMonster(mons *monster, ev event) {
 		}
 		for _, p := range neighbors {
 			m := g.MonsterAt(p)
-			if m.Exists() {
+			if m.Exists() && !m.Ally {
 				g.HitMonster(DmgPhysical, g.Player.Attack(), m, ev)
 			}
 		}
@@ -197,7 +274,7 @@ func (g *game) AttackMonster(mons *monster, ev event) {
 		behind := To(To(g.Player.P, dir), dir)
 		if valid(behind) {
 			m := g.MonsterAt(behind)
-			if m.Exists() {
+			if m.Exists() && !m.Ally {
 				g.HitMonster(DmgPhysical, g.Player.Attack(), m, ev)
 			}
 		}
@@ -206,55 +283,41 @@ func (g *game) AttackMonster(mons *monster, ev event) {
 	case g.Player.Weapon == DancingRapier:
 		ompos := mons.P
 		g.HitMonster(DmgPhysical, g.Player.Attack(), mons, ev)
-		if g.Player.HasStatus(StatusLignification) || mons.Status(MonsLignified) || mons.Kind == MonsTinyHarpy {
+		if g.Player.HasStatus(StatusLignification) || mons.Status(StatusLignification) || mons.Kind == MonsTinyHarpy {
 			break
 		}
 		dir := Dir(ompos, g.Player.P)
 		behind := To(To(g.Player.P, dir), dir)
 		if valid(behind) {
 			m := g.MonsterAt(behind)
-			if m.Exists() {
+			if m.Exists() && !m.Ally {
 				g.HitMonster(DmgPhysical, g.Player.Attack()+3, m, ev)
 			}
 		}
 		if mons.Exists() {
-			mons.MoveTo(g, g.Player.P)
+			mons.MoveTo(g, g.Player.P, ev)
 		}
 		g.PlacePlayerAt(ompos)
 	case g.Player.Weapon == HarKarGauntlets:
 		g.HarKarAttack(mons, ev)
-	case g.Player.Weapon == HopeSword:
-		attack := g.Player.Attack()
-		fact := -60 + 100*DefaultHealth/g.Player.HP
-		if fact < 100 {
-			fact = 100
-		}
-		if fact > 250 {
-			fact = 250
-		}
-		attack *= fact
-		attack /= 100
-		g.HitMonster(DmgPhysical, attack, mons, ev)
-	case g.Player.Weapon == DragonSabre:
-		mfact := 100 * (mons.HPmax * mons.HPmax) / (45 * 45)
-		bonus := -1 + 13*mfact/100
-		g.HitMonster(DmgPhysical, g.Player.Attack()+bonus, mons, ev)
+	case g.Player.Weapon == HopeSword || g.Player.Weapon == DragonSabre:
+		dt, attack := g.PlayerMeleeAttack(mons)
+		g.HitMonster(dt, attack, mons, ev)
 	case g.Player.Weapon == DefenderFlail:
-		bonus := g.Player.Statuses[StatusSlay]
-		g.HitMonster(DmgPhysical, g.Player.Attack()+bonus, mons, ev)
-		g.Player.Statuses[StatusSlay]++
-		g.PushEvent(&simpleEvent{ERank: ev.Rank() + 60, EAction: SlayEnd})
+		dt, attack := g.PlayerMeleeAttack(mons)
+		g.HitMonster(dt, attack, mons, ev)
+		g.PutStatus(StatusSlay, ev)
 	default:
 		g.HitMonster(DmgPhysical, g.Player.Attack(), mons, ev)
 	}
 }
 
-func (g *game) AttractMonster(p gruid.Point) *monster {
+func (g *game) AttractMonster(p gruid.Point, ev event) *monster {
 	dir := Dir(p, g.Player.P)
 	for cpos := To(p, dir); g.Player.LOS[cpos]; cpos = To(cpos, dir) {
 		mons := g.MonsterAt(cpos)
 		if mons.Exists() {
-			mons.MoveTo(g, p)
+			mons.MoveTo(g, p, ev)
 			g.ui.TeleportAnimation(cpos, p, false)
 			return mons
 		}
@@ -357,7 +420,9 @@ const (
 	DmgMagical
 )
 
-func (g *game) HitMonster(dt dmgType, dmg int, mons *monster, ev event) (hit bool) {
+// PlayerAccuracy returns the player's maximal accuracy roll against a
+// monster.
+func (g *game) PlayerAccuracy(mons *monster) int {
 	maxacc := g.Player.Accuracy()
 	if g.Player.Weapon == AssassinSabre && mons.HP > 0 {
 		adjust := 6 * (-100 + 100*mons.HPmax/mons.HP) / 100
@@ -368,23 +433,119 @@ func (g *game) HitMonster(dt dmgType, dmg int, mons *monster, ev event) (hit boo
 	} else if g.Player.Weapon == FinalBlade {
 		maxacc += 10
 	}
+	return maxacc
+}
+
+// EvasionRoll returns the monster's effective evasion for an evasion roll.
+func (m *monster) EvasionRoll(evasion int) int {
+	if m.State == Resting {
+		evasion /= 2 + 1
+	}
+	return evasion
+}
+
+// DefenseArmor returns the monster's armor against the player's attacks.
+func (m *monster) DefenseArmor() int {
+	return 6 + m.Armor/2
+}
+
+// PlayerAttackBonus returns a random bonus to the player's attack against a
+// monster.
+func (g *game) PlayerAttackBonus(mons *monster) int {
+	bonus, dice := g.PlayerAttackBonusDice(mons)
+	for _, n := range dice {
+		bonus += RandInt(n)
+	}
+	return bonus
+}
+
+// PlayerAttackBonusDice returns the fixed part of the player's attack bonus
+// against a monster, and the dice rolled for the random part.
+func (g *game) PlayerAttackBonusDice(mons *monster) (bonus int, dice []int) {
+	if g.Player.HasStatus(StatusBerserk) {
+		bonus += 2
+		dice = append(dice, 4)
+	}
+	if g.Player.Weapon.Cleave() && g.InOpenMons(mons) {
+		bonus++
+		if g.Player.Attack() >= 15 {
+			dice = append(dice, 3)
+		} else {
+			dice = append(dice, 2)
+		}
+	}
+	return bonus, dice
+}
+
+// PlayerMeleeAttack returns the damage type and base attack of the player's
+// melee attack against a monster.
+func (g *game) PlayerMeleeAttack(mons *monster) (dmgType, int) {
+	attack := g.Player.Attack()
+	switch g.Player.Weapon {
+	case ElecWhip:
+		return DmgMagical, attack
+	case HopeSword:
+		fact := -60 + 100*DefaultHealth/g.Player.HP
+		if fact < 100 {
+			fact = 100
+		}
+		if fact > 250 {
+			fact = 250
+		}
+		attack *= fact
+		attack /= 100
+	case DragonSabre:
+		mfact := 100 * (mons.HPmax * mons.HPmax) / (45 * 45)
+		attack += -1 + 13*mfact/100
+	case DefenderFlail:
+		attack += g.Player.Statuses[StatusSlay]
+	}
+	return DmgPhysical, attack
+}
+
+// AccuracyStreak returns the adjustment of an accuracy roll out of maxacc,
+// given the player's accuracy score: the roll is adjusted by
+// sign*RandInt(n).
+func AccuracyStreak(acc, maxacc, score int) (sign, n int) {
+	switch {
+	case score == 1 && acc >= maxacc/2:
+		return -1, 1 + maxacc/2
+	case score == -1 && acc < maxacc/2:
+		return 1, 1 + maxacc/2
+	}
+	return 0, 0
+}
+
+// SneakAttackFactor returns the damage multiplier against a monster.
+func (g *game) SneakAttackFactor(mons *monster) int {
+	if mons.State != Resting {
+		return 1
+	}
+	if g.Player.Weapon == Dagger || g.Player.Weapon == VampDagger {
+		return 4
+	}
+	return 2
+}
+
+func (g *game) HitMonster(dt dmgType, dmg int, mons *monster, ev event) (hit bool) {
+	maxacc := g.PlayerAccuracy(mons)
 	acc := RandInt(maxacc)
-	if g.Player.AccScore == 1 && acc >= maxacc/2 {
-		acc -= RandInt(1 + maxacc/2)
-	} else if g.Player.AccScore == -1 && acc < maxacc/2 {
-		acc += RandInt(1 + maxacc/2)
+	rolled := acc
+	if sign, n := AccuracyStreak(acc, maxacc, g.Player.AccScore); sign != 0 {
+		acc += sign * RandInt(n)
+	}
+	if acc != rolled {
+		g.CombatPrintf("accuracy streak adjustment: %d → %d", rolled, acc)
 	}
 	if acc >= maxacc/2 {
 		g.Player.AccScore = 1
 	} else {
 		g.Player.AccScore = -1
 	}
-	evasion := RandInt(mons.Evasion)
-	if mons.State == Resting {
-		evasion /= 2 + 1
-	}
-	if acc > evasion || g.Player.HasStatus(StatusAccurate) {
-		hit = true
+	evasion := mons.EvasionRoll(RandInt(mons.Evasion))
+	hit = acc > evasion || g.Player.HasStatus(StatusAccurate)
+	g.CombatLogPlayerAttack(mons, acc, maxacc, evasion, hit)
+	if hit {
 		noise := BaseHitNoise
 		if g.Player.Weapon == Dagger || g.Player.Weapon == VampDagger {
 			noise -= 2
@@ -395,32 +556,16 @@ func (g *game) HitMonster(dt dmgType, dmg int, mons *monster, ev event) (hit boo
 		if g.Player.Weapon == Frundis {
 			noise -= 5
 		}
-		bonus := 0
-		if g.Player.HasStatus(StatusBerserk) {
-			bonus += 2 + RandInt(4)
-		}
-		pa := dmg + bonus
-		if g.Player.Weapon.Cleave() && g.InOpenMons(mons) {
-			if g.Player.Attack() >= 15 {
-				pa += 1 + RandInt(3)
-			} else {
-				pa += 1 + RandInt(2)
-			}
-		}
-		marmor := mons.Armor
-		marmor = 6 + marmor/2
-		attack, clang := g.HitDamage(dt, pa, marmor)
+		pa := dmg + g.PlayerAttackBonus(mons)
+		marmor := mons.DefenseArmor()
+		dr := g.DamageRoll(dt, pa, marmor)
+		attack, clang := dr.Damage(), dr.Clang
 		if clang {
 			noise += marmor
 		}
 		g.MakeNoise(noise, mons.P)
-		if mons.State == Resting {
-			if g.Player.Weapon == Dagger || g.Player.Weapon == VampDagger {
-				attack *= 4
-			} else {
-				attack *= 2
-			}
-		}
+		factor := g.SneakAttackFactor(mons)
+		attack *= factor
 		var sclang string
 		if clang {
 			if marmor > 3 {
@@ -435,6 +580,8 @@ func (g *game) HitMonster(dt dmgType, dmg int, mons *monster, ev event) (hit boo
 				attack = mons.HP
 			}
 		}
+		g.CombatPrintf("damage to %s: %s, bonus %d, ×%d sneak attack = %d dmg",
+			mons.Kind.Definite(false), dr, pa-dmg, factor, attack)
 		mons.HP -= attack
 		if g.Player.Weapon == VampDagger && mons.Kind.Living() {
 			healing := attack
@@ -453,16 +600,15 @@ func (g *game) HitMonster(dt dmgType, dmg int, mons *monster, ev event) (hit boo
 		} else if oldHP > 0 {
 			// test oldHP > 0 because of sword special attack
 			g.PrintfStyled("You kill %s (%d dmg).%s", logPlayerHit, mons.Kind.Definite(false), attack, sclang)
-			g.HandleKill(mons, ev)
+			g.HandleKill(mons, KillByPlayer, ev)
 		}
 		if mons.Kind == MonsBrizzia && RandInt(4) == 0 && !g.Player.HasStatus(StatusNausea) &&
 			Distance(mons.P, g.Player.P) == 1 {
-			g.Player.Statuses[StatusNausea]++
-			g.PushEvent(&simpleEvent{ERank: ev.Rank() + 30 + RandInt(20), EAction: NauseaEnd})
+			g.PutStatus(StatusNausea, ev)
 			g.Print("The brizzia's corpse releases some nauseating gas. You feel sick.")
 		}
 		if mons.Kind == MonsTinyHarpy && mons.HP > 0 {
-			mons.Blink(g)
+			mons.Blink(g, ev)
 		}
 		g.HandleStone(mons)
 		g.Stats.Hits++
@@ -479,11 +625,12 @@ func (g *game) HandleStone(mons *monster) {
 	if !ok {
 		return
 	}
+	g.CombatPrintf("%s triggered under %s", stn, mons.Kind.Definite(false))
 	switch stn {
 	case TeleStone:
 		if mons.Exists() {
 			g.UseStone(mons.P)
-			mons.TeleportAway(g)
+			mons.TeleportAway(g, g.Ev)
 		}
 	case FogStone:
 		g.Fog(mons.P, 3, g.Ev)
@@ -537,22 +684,36 @@ func (g *game) HandleStone(mons *monster) {
 	}
 }
 
-func (g *game) HandleKill(mons *monster, ev event) {
-	g.Stats.Killed++
-	g.Stats.KilledMons[mons.Kind]++
+func (g *game) HandleKill(mons *monster, src killSource, ev event) {
+	switch src {
+	case KillByPlayer:
+		g.Stats.Killed++
+		g.Stats.KilledMons[mons.Kind]++
+	case KillByAlly:
+		g.Stats.AllyKills++
+	case KillByMonster:
+		g.Stats.InfightingKills++
+	case KillByEnvironment:
+		g.Stats.EnvironmentKills++
+	}
+	if mons.Ally {
+		g.PrintfStyled("Your ally %s dies.", logCritic, mons.Kind.String())
+		g.StoryPrintf("Lost ally %s.", mons.Kind.Indefinite(false))
+	}
 	if mons.Kind == MonsExplosiveNadre {
 		mons.Explode(g, ev)
 	}
 	if g.Doors[mons.P] {
 		g.ComputeLOS()
 	}
-	if mons.Kind.Dangerousness() > 10 {
+	if src == KillByPlayer && mons.Kind.Dangerousness() > 10 {
 		g.StoryPrintf("Killed %s.", mons.Kind.Indefinite(false))
 	}
 }
 
 const (
 	WallNoise           = 18
+	DigNoise            = 16
 	TemporalWallNoise   = 13
 	ExplosionHitNoise   = 13
 	ExplosionNoise      = 18
@@ -589,6 +750,7 @@ func (g *game) BlockEffects(m *monster) {
 			}
 			if RandInt(3) == 0 && g.Dungeon.Cell(p).T == WallCell {
 				g.Dungeon.SetCell(p, FreeCell)
+				g.InvalidatePathMaps()
 				g.Stats.Digs++
 				g.MakeNoise(WallNoise+3, p)
 				g.Fog(p, 1, g.Ev)
@@ -623,7 +785,7 @@ func (g *game) BlockEffects(m *monster) {
 		}
 		m.Exhaust(g)
 		if p != m.P {
-			m.MoveTo(g, p)
+			m.MoveTo(g, p, g.Ev)
 			g.Printf("%s is repelled.", m.Kind.Definite(true))
 		}
 	case ConfusingShield:
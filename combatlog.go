@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CombatPrintf records a line in the verbose combat log, if enabled in the
+// settings. Combat log entries are kept apart from the message log, but share
+// its indices, so that both can be merged in the previous messages view. They
+// are not saved with the game.
+func (g *game) CombatPrintf(format string, a ...interface{}) {
+	if !GameConfig.VerboseCombatLog {
+		return
+	}
+	s := fmt.Sprintf("Depth %2d|Turn %5d| %s", g.Depth, g.Turn/10, fmt.Sprintf(format, a...))
+	g.combatLog = append(g.combatLog, logEntry{Text: s, Index: g.LogIndex, Style: logCombat})
+	if len(g.combatLog) > 100000 {
+		g.combatLog = g.combatLog[10000:]
+	}
+}
+
+// damageRoll records the rolls of a damage computation.
+type damageRoll struct {
+	Base   int
+	Attack int // attack roll, before armor absorption
+	Absorb int
+	Armor  int
+	Clang  bool
+}
+
+func (dr damageRoll) Damage() int {
+	return AbsorbDamage(dr.Attack, dr.Absorb)
+}
+
+func (dr damageRoll) String() string {
+	s := fmt.Sprintf("attack %d (%d-%d) − absorb %d (armor %d)", dr.Attack, dr.Base/2, dr.Base, dr.Absorb, dr.Armor)
+	if dr.Clang {
+		s += ", clang"
+	}
+	return s
+}
+
+// CombatLogAttack records a monster's attack against the player in the
+// verbose combat log.
+func (m *monster) CombatLogAttack(g *game, what string, acc, evasion int, dr damageRoll, attack int, hit bool) {
+	if !GameConfig.VerboseCombatLog {
+		return
+	}
+	s := fmt.Sprintf("%s %s: accuracy %d (max %d) vs evasion %d (max %d): ",
+		m.Kind.Definite(true), what, acc, m.Accuracy, evasion, g.Player.Evasion())
+	if hit {
+		s += fmt.Sprintf("hit, %s = %d dmg", dr, attack)
+	} else {
+		s += "miss"
+	}
+	g.CombatPrintf("%s", s)
+}
+
+type logFilter int
+
+const (
+	LogMessages logFilter = iota
+	LogMessagesAndCombat
+	LogCombat
+)
+
+func (f logFilter) String() (text string) {
+	switch f {
+	case LogMessages:
+		text = "messages"
+	case LogMessagesAndCombat:
+		text = "messages and combat"
+	case LogCombat:
+		text = "combat"
+	}
+	return text
+}
+
+// Next returns the next filter, cycling.
+func (f logFilter) Next() logFilter {
+	return (f + 1) % (LogCombat + 1)
+}
+
+// FilteredLog returns the log entries shown with a given filter. Combat
+// entries come before the message that followed them.
+func (g *game) FilteredLog(f logFilter) []logEntry {
+	switch f {
+	case LogMessages:
+		return g.Log
+	case LogCombat:
+		return g.combatLog
+	}
+	log := make([]logEntry, 0, len(g.Log)+len(g.combatLog))
+	i := 0
+	for _, e := range g.Log {
+		for i < len(g.combatLog) && g.combatLog[i].Index <= e.Index {
+			log = append(log, g.combatLog[i])
+			i++
+		}
+		log = append(log, e)
+	}
+	return append(log, g.combatLog[i:]...)
+}
+
+// CombatLogText returns the verbose combat log as text, for exporting.
+func (g *game) CombatLogText() string {
+	buf := &strings.Builder{}
+	for _, e := range g.combatLog {
+		fmt.Fprintf(buf, "%s\n", e.Text)
+	}
+	return buf.String()
+}
+
+// CombatLogPlayerAttack records the player's accuracy roll against a monster
+// in the verbose combat log.
+func (g *game) CombatLogPlayerAttack(mons *monster, acc, maxacc, evasion int, hit bool) {
+	if !GameConfig.VerboseCombatLog {
+		return
+	}
+	s := fmt.Sprintf("You attack %s: accuracy %d (max %d) vs evasion %d (max %d", mons.Kind.Definite(false), acc, maxacc, evasion, mons.Evasion)
+	if mons.State == Resting {
+		s += ", resting"
+	}
+	s += "): "
+	switch {
+	case g.Player.HasStatus(StatusAccurate):
+		s += "hit (accurate)"
+	case hit:
+		s += "hit"
+	default:
+		s += "miss"
+	}
+	g.CombatPrintf("%s", s)
+}
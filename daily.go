@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DailyDate returns the date identifying the daily challenge of the given
+// time.
+func DailyDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func dailySeed(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("boohu-daily-" + key))
+	return int64(h.Sum64() >> 1)
+}
+
+type dailyPhase int
+
+const (
+	DailyTerrain dailyPhase = iota
+	DailyMonsters
+	DailyItems
+	DailyEvents
+)
+
+// DailyReseed seeds the random number generator for a given level
+// generation phase of a daily run. Each phase uses its own seed, so that
+// player-dependent random draws in one phase do not change what is generated
+// in the next ones.
+func (g *game) DailyReseed(phase dailyPhase) {
+	if g.Opts.Daily == "" {
+		return
+	}
+	key := fmt.Sprintf("%s-%d-%d-%d-%d", g.Opts.Daily, g.Branch, g.BranchDepth, g.Depth, phase)
+	rand.Seed(dailySeed(key))
+}
+
+// DailyEndGeneration restores a time-based seed after daily level generation.
+func (g *game) DailyEndGeneration() {
+	if g.Opts.Daily == "" {
+		return
+	}
+	rand.Seed(time.Now().UnixNano())
+}
+
+func (g *game) Escaped() bool {
+	return g.Player.HP > 0 && g.Depth == -1
+}
+
+func (g *game) MaxDepthReached() int {
+	// in revisit mode, the player may have come back up
+	return Max(Max(g.Depth, g.ExploredLevels), len(g.Stats.DLayout)-1)
+}
+
+// Score returns the final score of the game: collected simellas, with a bonus
+// for each explored level and for escaping alive.
+func (g *game) Score() int {
+	score := g.Player.Simellas
+	score += 50 * g.MaxDepthReached()
+	if g.Opts.Endless {
+		// going deeper is what matters most in endless mode
+		score += 100 * Max(g.MaxDepthReached()-MaxDepth, 0)
+	}
+	if g.Escaped() {
+		score += 500
+	}
+	return score
+}
+
+type dailyScore struct {
+	Date   string
+	Name   string
+	Score  int
+	Depth  int
+	Turns  int
+	Result string
+}
+
+func (ds dailyScore) String() string {
+	return strings.Join([]string{ds.Date, ds.Name, strconv.Itoa(ds.Score),
+		strconv.Itoa(ds.Depth), strconv.Itoa(ds.Turns), ds.Result}, "\t")
+}
+
+func parseDailyScore(line string) (ds dailyScore, err error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 6 {
+		return ds, fmt.Errorf("invalid daily score line: %q", line)
+	}
+	ds.Date = fields[0]
+	ds.Name = fields[1]
+	ds.Result = fields[5]
+	for i, n := range []*int{&ds.Score, &ds.Depth, &ds.Turns} {
+		*n, err = strconv.Atoi(fields[2+i])
+		if err != nil {
+			return ds, fmt.Errorf("invalid daily score line: %q", line)
+		}
+	}
+	return ds, nil
+}
+
+// parseDailyScores returns the scores recorded for a given date, best ones
+// first.
+func parseDailyScores(data []byte, date string) ([]dailyScore, error) {
+	scores := []dailyScore{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		ds, err := parseDailyScore(line)
+		if err != nil {
+			return scores, err
+		}
+		if ds.Date == date {
+			scores = append(scores, ds)
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	return scores, nil
+}
+
+func (g *game) NewDailyScore(name string) dailyScore {
+	ds := dailyScore{Date: g.Opts.Daily, Name: name, Score: g.Score(),
+		Depth: g.MaxDepthReached(), Turns: g.Turn / 10}
+	switch {
+	case g.Escaped():
+		ds.Result = "escaped"
+	case g.Player.HP <= 0:
+		ds.Result = "died"
+	default:
+		ds.Result = "quit"
+	}
+	return ds
+}
+
+func (g *game) DailyLeaderboard(scores []dailyScore) string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Daily challenge %s leaderboard:\n", g.Opts.Daily)
+	for i, ds := range scores {
+		if i >= 8 {
+			break
+		}
+		fmt.Fprintf(buf, "%d. %-12.12s %6d (depth %d, %d turns, %s)\n", i+1, ds.Name, ds.Score, ds.Depth, ds.Turns, ds.Result)
+	}
+	return buf.String()
+}
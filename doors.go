@@ -0,0 +1,279 @@
+package main
+
+import (
+	"errors"
+
+	"codeberg.org/anaseto/gruid"
+	"codeberg.org/anaseto/gruid/paths"
+)
+
+// doorState is the state of a door. Doors are closed by default.
+type doorState int
+
+const (
+	DoorClosed doorState = iota
+	DoorOpen
+	DoorLocked
+	DoorBarred
+)
+
+func (ds doorState) String() (text string) {
+	switch ds {
+	case DoorClosed:
+		text = "door"
+	case DoorOpen:
+		text = "open door"
+	case DoorLocked:
+		text = "locked door"
+	case DoorBarred:
+		text = "barred door"
+	}
+	return text
+}
+
+func (ds doorState) Description() (text string) {
+	switch ds {
+	case DoorClosed:
+		text = "A closed door blocks your line of sight. Doors open automatically when you or a monster stand on them, but some animals cannot open them. Doors are flammable."
+	case DoorOpen:
+		text = "An open door does not block your line of sight, and any creature can pass through it. Doors are flammable."
+	case DoorLocked:
+		text = "A locked door blocks your line of sight and the way of any creature. You can unlock it if you have a key. Doors are flammable."
+	case DoorBarred:
+		text = "A barred door blocks your line of sight and the way of any creature. It can be opened by pulling a lever somewhere in the level. Doors are flammable."
+	}
+	return text
+}
+
+// lever opens a barred door somewhere in the level.
+type lever struct {
+	Door   gruid.Point
+	Pulled bool
+}
+
+const LeverNoise = 15
+
+// DoorState returns the state of the door at a given position.
+func (g *game) DoorState(p gruid.Point) doorState {
+	return g.DoorStates[p]
+}
+
+// SetDoorState changes the state of the door at a given position.
+func (g *game) SetDoorState(p gruid.Point, st doorState) {
+	if st == DoorClosed {
+		delete(g.DoorStates, p)
+	} else {
+		g.DoorStates[p] = st
+	}
+	g.InvalidatePathMaps()
+	g.ComputeLOS()
+}
+
+// ClosedDoor reports whether there is a door that is not open at a given
+// position.
+func (g *game) ClosedDoor(p gruid.Point) bool {
+	return g.Doors[p] && g.DoorStates[p] != DoorOpen
+}
+
+// LockedDoor reports whether there is a locked or barred door at a given
+// position.
+func (g *game) LockedDoor(p gruid.Point) bool {
+	if !g.Doors[p] {
+		return false
+	}
+	st := g.DoorStates[p]
+	return st == DoorLocked || st == DoorBarred
+}
+
+// CanOpenDoors reports whether monsters of this kind can open closed doors.
+func (mk monsterKind) CanOpenDoors() bool {
+	switch mk {
+	case MonsWorm, MonsBrizzia, MonsHound, MonsYack, MonsGiantBee, MonsHydra, MonsSpider,
+		MonsBlinkingFrog, MonsAcidMound, MonsExplosiveNadre, MonsSatowalgaPlant, MonsCaveViper:
+		return false
+	default:
+		return true
+	}
+}
+
+// DoorBlocks reports whether a door prevents the monster from moving to a
+// given position.
+func (m *monster) DoorBlocks(g *game, p gruid.Point) bool {
+	return g.DoorBlocks(p, m.Kind.CanOpenDoors())
+}
+
+// DoorBlocks reports whether a door prevents monsters from moving to a given
+// position, depending on whether they can open closed doors.
+func (g *game) DoorBlocks(p gruid.Point, open bool) bool {
+	if !g.Doors[p] {
+		return false
+	}
+	switch g.DoorStates[p] {
+	case DoorOpen:
+		return false
+	case DoorLocked, DoorBarred:
+		return true
+	default:
+		return !open
+	}
+}
+
+// OpenLockedDoor makes the player try to open a locked or barred door.
+func (g *game) OpenLockedDoor(p gruid.Point, ev event) error {
+	if g.DoorState(p) == DoorBarred {
+		return errors.New("This door is barred. A lever somewhere may open it.")
+	}
+	if g.Player.Keys == 0 {
+		return errors.New("This door is locked. You need a key to open it.")
+	}
+	g.Player.Keys--
+	g.SetDoorState(p, DoorOpen)
+	g.Print("You unlock the door with a key.")
+	g.StoryPrint("Unlocked a door.")
+	ev.Renew(g, g.ActionDelay(10))
+	return nil
+}
+
+// PullLever pulls the lever at the player's position, if any, opening the
+// barred door it controls.
+func (g *game) PullLever() {
+	lv, ok := g.Levers[g.Player.P]
+	if !ok || lv.Pulled {
+		return
+	}
+	lv.Pulled = true
+	g.Levers[g.Player.P] = lv
+	g.StopAuto()
+	g.StoryPrint("Pulled a lever.")
+	if g.DoorState(lv.Door) != DoorBarred {
+		g.Print("You pull the lever. Nothing happens.")
+		return
+	}
+	g.SetDoorState(lv.Door, DoorOpen)
+	g.MakeNoise(LeverNoise, lv.Door)
+	if g.Player.LOS[lv.Door] {
+		g.Print("You pull the lever. The barred door opens.")
+	} else {
+		g.Print("You pull the lever. You hear a distant grinding sound.")
+	}
+}
+
+type vaultPath struct {
+	game *game
+	door gruid.Point
+	nbs  paths.Neighbors
+}
+
+func (vp *vaultPath) Neighbors(p gruid.Point) []gruid.Point {
+	d := vp.game.Dungeon
+	keep := func(np gruid.Point) bool {
+		return valid(np) && d.Cell(np).T != WallCell && np != vp.door
+	}
+	return vp.nbs.All(p, keep)
+}
+
+func (vp *vaultPath) Cost(from, to gruid.Point) int {
+	return 1
+}
+
+// VaultArea returns the small area only reachable through the door at a
+// given position, if any.
+func (g *game) VaultArea(door gruid.Point) []gruid.Point {
+	const radius = 15
+	const maxSize = 60
+	for _, q := range g.Dungeon.FreeNeighbors(door) {
+		nodes := g.PR.BreadthFirstMap(&vaultPath{game: g, door: door}, []gruid.Point{q}, radius)
+		if len(nodes) > maxSize {
+			continue
+		}
+		area := []gruid.Point{}
+		for _, n := range nodes {
+			if n.Cost >= radius || !g.VaultCandidate(n.P) {
+				area = nil
+				break
+			}
+			area = append(area, n.P)
+		}
+		if len(area) > 0 {
+			return area
+		}
+	}
+	return nil
+}
+
+// VaultCandidate reports whether a position can be part of a new vault.
+func (g *game) VaultCandidate(p gruid.Point) bool {
+	if _, ok := g.Stairs[p]; ok {
+		return false
+	}
+	if _, ok := g.Levers[p]; ok {
+		return false
+	}
+	if f, ok := g.Features[p]; ok && f == Merchant {
+		return false
+	}
+	return p != g.Player.P && !g.Vault[p] && !g.Keys[p] && !g.LockedDoor(p)
+}
+
+// FreeCellOutsideVaults returns a free cell for a key or lever, that is not
+// inside a vault.
+func (g *game) FreeCellOutsideVaults() gruid.Point {
+	for {
+		p := g.FreeCellForStatic()
+		if g.VaultCandidate(p) {
+			return p
+		}
+	}
+}
+
+// GenDoorStates opens some doors, and locks or bars a few doors leading to
+// small areas, turning them into vaults. A key or a lever is placed elsewhere
+// in the level for each vault, and some simellas inside.
+func (g *game) GenDoorStates() {
+	g.DoorStates = map[gruid.Point]doorState{}
+	g.Keys = map[gruid.Point]bool{}
+	g.Levers = map[gruid.Point]lever{}
+	g.Vault = map[gruid.Point]bool{}
+	doors := []gruid.Point{}
+	for i := range g.Dungeon.Cells {
+		p := idx2Point(i)
+		if !g.Doors[p] {
+			continue
+		}
+		doors = append(doors, p)
+		if RandInt(5) == 0 {
+			g.DoorStates[p] = DoorOpen
+		}
+	}
+	if g.Depth < 2 || len(doors) == 0 {
+		return
+	}
+	nvaults := RandInt(3)
+	for i := 0; i < 10 && nvaults > 0; i++ {
+		door := doors[RandInt(len(doors))]
+		if !g.VaultCandidate(door) {
+			// already a vault door, or the player's starting position
+			continue
+		}
+		area := g.VaultArea(door)
+		if area == nil {
+			continue
+		}
+		nvaults--
+		for _, p := range area {
+			g.Vault[p] = true
+		}
+		g.Vault[door] = true
+		if RandInt(2) == 0 {
+			g.DoorStates[door] = DoorLocked
+			g.Keys[g.FreeCellOutsideVaults()] = true
+		} else {
+			g.DoorStates[door] = DoorBarred
+			g.Levers[g.FreeCellOutsideVaults()] = lever{Door: door}
+		}
+		p := area[RandInt(len(area))]
+		if !g.Doors[p] {
+			g.Simellas[p] += 2 + RandInt(2*g.DangerDepth())
+		}
+	}
+}
@@ -160,10 +160,14 @@ var (
 	ColorBgDark,
 	ColorBgLOS,
 	ColorFg,
+	ColorFgAlly,
 	ColorFgAnimationHit,
 	ColorFgCollectable,
 	ColorFgConfusedMonster,
+	ColorFgEnragedMonster,
+	ColorFgLight,
 	ColorFgLignifiedMonster,
+	ColorFgLockedDoor,
 	ColorFgSlowedMonster,
 	ColorFgDark,
 	ColorFgExcluded,
@@ -182,6 +186,7 @@ var (
 	ColorFgMonster,
 	ColorFgPlace,
 	ColorFgPlayer,
+	ColorFgPoisonGas,
 	ColorFgProjectile,
 	ColorFgSimellas,
 	ColorFgSleepingMonster,
@@ -190,6 +195,7 @@ var (
 	ColorFgStatusExpire,
 	ColorFgStatusOther,
 	ColorFgTargetMode,
+	ColorFgTrap,
 	ColorFgWanderingMonster uicolor
 )
 
@@ -201,10 +207,14 @@ func LinkColors() {
 	ColorFg = ColorBase0
 	ColorFgDark = ColorBase01
 	ColorFgLOS = ColorBase0
+	ColorFgAlly = ColorBlue
 	ColorFgAnimationHit = ColorMagenta
 	ColorFgCollectable = ColorYellow
 	ColorFgConfusedMonster = ColorGreen
+	ColorFgEnragedMonster = ColorMagenta
+	ColorFgLight = ColorYellow
 	ColorFgLignifiedMonster = ColorYellow
+	ColorFgLockedDoor = ColorOrange
 	ColorFgSlowedMonster = ColorCyan
 	ColorFgExcluded = ColorRed
 	ColorFgExplosionEnd = ColorOrange
@@ -221,6 +231,7 @@ func LinkColors() {
 	ColorFgMonster = ColorRed
 	ColorFgPlace = ColorMagenta
 	ColorFgPlayer = ColorBlue
+	ColorFgPoisonGas = ColorGreen
 	ColorFgProjectile = ColorBlue
 	ColorFgSimellas = ColorYellow
 	ColorFgSleepingMonster = ColorViolet
@@ -229,6 +240,7 @@ func LinkColors() {
 	ColorFgStatusExpire = ColorViolet
 	ColorFgStatusOther = ColorYellow
 	ColorFgTargetMode = ColorCyan
+	ColorFgTrap = ColorRed
 	ColorFgWanderingMonster = ColorOrange
 }
 
@@ -532,7 +544,7 @@ func (ui *gameui) KeysHelp() {
 		"Movement", "h/j/k/l/y/u/b/n or numpad or mouse left",
 		"Wait a turn", "“.” or 5 or mouse left on @",
 		"Rest (until status free or regen)", "r",
-		"Descend stairs", "> or D",
+		"Take stairs", "> or < or D",
 		"Go to nearest stairs", "G",
 		"Autoexplore", "o",
 		"Examine", "x or mouse left",
@@ -674,6 +686,10 @@ func (ui *gameui) DescribePosition(p gruid.Point, targ Targeter) {
 	}
 	strt, okStair := g.Stairs[p]
 	stn, okStone := g.MagicalStones[p]
+	trp, okTrap := g.Traps[p]
+	lgt, okLight := g.Lights[p]
+	lv, okLever := g.Levers[p]
+	f, okFeature := g.Features[p]
 	switch {
 	case g.Simellas[p] > 0:
 		desc = ui.AddComma(see, desc)
@@ -696,6 +712,12 @@ func (ui *gameui) DescribePosition(p gruid.Point, targ Targeter) {
 		if strt == WinStair {
 			desc = ui.AddComma(see, desc)
 			desc += "glowing monolith"
+		} else if strt == UpStair {
+			desc = ui.AddComma(see, desc)
+			desc += "stairs upwards"
+		} else if strt == BranchStair {
+			desc = ui.AddComma(see, desc)
+			desc += "stairs to a side branch"
 		} else {
 			desc = ui.AddComma(see, desc)
 			desc += "stairs downwards"
@@ -703,29 +725,53 @@ func (ui *gameui) DescribePosition(p gruid.Point, targ Targeter) {
 	case okStone:
 		desc = ui.AddComma(see, desc)
 		desc += fmt.Sprint(Indefinite(stn.String(), false))
+	case okFeature:
+		desc = ui.AddComma(see, desc)
+		desc += Indefinite(f.String(), false)
+	case okTrap && g.KnownTraps[p]:
+		desc = ui.AddComma(see, desc)
+		desc += fmt.Sprint(Indefinite(trp.String(), false))
+	case g.Keys[p]:
+		desc = ui.AddComma(see, desc)
+		desc += "a key"
+	case okLever && lv.Pulled:
+		desc = ui.AddComma(see, desc)
+		desc += "a pulled lever"
+	case okLever:
+		desc = ui.AddComma(see, desc)
+		desc += "a lever"
 	case g.Doors[p] || g.WrongDoor[p]:
 		desc = ui.AddComma(see, desc)
-		desc += "a door"
+		desc += Indefinite(g.DoorState(p).String(), false)
+	case okLight && lgt == Brazier:
+		desc = ui.AddComma(see, desc)
+		desc += "a brazier"
 	}
 	if cld, ok := g.Clouds[p]; ok && g.Player.LOS[p] {
-		if cld == CloudFire {
-			desc = ui.AddComma(see, desc)
-			desc += "burning flames"
-		} else if cld == CloudNight {
-			desc = ui.AddComma(see, desc)
-			desc += "night clouds"
+		desc = ui.AddComma(see, desc)
+		desc += cld.String()
+	} else if _, ok := g.Fungus[p]; ok && !g.WrongFoliage[p] || !ok && g.WrongFoliage[p] {
+		desc = ui.AddComma(see, desc)
+		if okLight && lgt == GlowingFungus {
+			desc += "glowing foliage"
 		} else {
-			desc = ui.AddComma(see, desc)
-			desc += "a dense fog"
+			desc += "foliage"
 		}
-	} else if _, ok := g.Fungus[p]; ok && !g.WrongFoliage[p] || !ok && g.WrongFoliage[p] {
+	} else if g.WoodenFloor[p] {
 		desc = ui.AddComma(see, desc)
-		desc += "foliage"
+		desc += "a wooden floor"
 	} else if desc == "" {
 		desc = ui.AddComma(see, desc)
 		desc += "the ground"
 	}
-	g.InfoEntry = desc + "."
+	desc += "."
+	if g.Dark(p) {
+		desc += " It is dark there."
+	}
+	if hn, ok := g.HeardNoise[p]; ok && !g.Player.LOS[p] {
+		desc += fmt.Sprintf(" You heard %s around there.", hn)
+	}
+	g.InfoEntry = desc
 }
 
 func (ui *gameui) ViewPositionDescription(p gruid.Point) {
@@ -748,12 +794,22 @@ func (ui *gameui) ViewPositionDescription(p gruid.Point) {
 	} else if strt, ok := g.Stairs[p]; ok {
 		if strt == WinStair {
 			desc := "This magical monolith will teleport you back to your village. It is said such monoliths were made some centuries ago by Marevor Helith. You can use it like stairs."
-			if g.Depth < MaxDepth {
+			if g.Depth < MaxDepth || g.Opts.Endless {
 				desc += " Note that this is not the last floor, so you may want to find a stair and continue collecting simellas, if you're courageous enough."
 			}
 			ui.DrawDescription(desc)
+		} else if strt == UpStair && g.Branch != NoBranch {
+			ui.DrawDescription(fmt.Sprintf("Stairs lead back to the main Underground, out of the %s. Only allies close to you follow you.", g.Branch))
+		} else if strt == UpStair {
+			ui.DrawDescription("Stairs lead back to the previous level of the Underground. Only allies close to you follow you.")
+		} else if strt == BranchStair {
+			b, _ := g.BranchEntry()
+			ui.DrawDescription(fmt.Sprintf("Stairs lead down to the %s, a side branch of the Underground. It is said something valuable can be found at its end. You can come back to this level using the up stairs there.", b))
 		} else {
-			desc := "Stairs lead to the next level of the Underground. There's no way back. Monsters do not follow you."
+			desc := "Stairs lead to the next level of the Underground. There's no way back. Only allies close to you follow you."
+			if g.Opts.Revisit {
+				desc = "Stairs lead to the next level of the Underground. Only allies close to you follow you."
+			}
 			if g.Depth == WinDepth {
 				desc += " If you're afraid, you could instead just win by taking the magical monolith somewhere in the same map."
 			}
@@ -761,10 +817,24 @@ func (ui *gameui) ViewPositionDescription(p gruid.Point) {
 		}
 	} else if stn, ok := g.MagicalStones[p]; ok {
 		ui.DrawDescription(stn.Description())
+	} else if f, ok := g.Features[p]; ok {
+		ui.DrawDescription(f.Description())
+	} else if t, ok := g.Traps[p]; ok && g.KnownTraps[p] {
+		ui.DrawDescription(t.Description())
+	} else if g.Keys[p] {
+		ui.DrawDescription("A key can be used to unlock a locked door. It is used up once the door is unlocked.")
+	} else if _, ok := g.Levers[p]; ok {
+		ui.DrawDescription("A lever opens a barred door somewhere in the level. You pull it by walking over it.")
 	} else if g.Doors[p] {
-		ui.DrawDescription("A closed door blocks your line of sight. Doors open automatically when you or a monster stand on them. Doors are flammable.")
+		ui.DrawDescription(g.DoorState(p).Description())
 	} else if g.Simellas[p] > 0 {
 		ui.DrawDescription("A simella is a plant with big white flowers which are used in the Underground for their medicinal properties. They can also make tasty infusions. You were actually sent here by your village to collect as many as possible of those plants.")
+	} else if cld, ok := g.Clouds[p]; ok && g.Player.LOS[p] {
+		ui.DrawDescription(cld.Description())
+	} else if l, ok := g.Lights[p]; ok {
+		ui.DrawDescription(l.Description())
+	} else if g.WoodenFloor[p] {
+		ui.DrawDescription("Old wooden planks cover the ground here. They burn quickly, and fire spreads easily through them.")
 	} else if _, ok := g.Fungus[p]; ok && g.Dungeon.Cell(p).T == FreeCell {
 		ui.DrawDescription("Blue dense foliage grows in the Underground. It is difficult to see through, and is flammable.")
 	} else if g.Dungeon.Cell(p).T == WallCell {
@@ -780,15 +850,21 @@ func (ui *gameui) MonsterInfo(m *monster) string {
 	if m.Kind == MonsSatowalgaPlant && m.State == Wandering {
 		state = "awaken"
 	}
+	if m.Ally {
+		state = "ally"
+	}
 	infos = append(infos, state)
 	for st, i := range m.Statuses {
 		if i > 0 {
-			infos = append(infos, monsterStatus(st).String())
+			infos = append(infos, status(st).Adjective())
 		}
 	}
 	p := (m.HP * 100) / m.HPmax
 	health := fmt.Sprintf("%d %% HP", p)
 	infos = append(infos, health)
+	if intent := m.Intent(ui.g); intent != IntentNone && ui.g.Player.LOS[m.P] && !m.Ally {
+		infos = append(infos, intent.String())
+	}
 	return strings.Join(infos, ", ")
 }
 
@@ -915,6 +991,10 @@ func (ui *gameui) PositionDrawing(p gruid.Point) (r rune, fgColor, bgColor uicol
 			r = '♫'
 			fgColor = ColorFgWanderingMonster
 		}
+		if hn, ok := g.HeardNoise[p]; ok {
+			r = '♫'
+			fgColor = hn.Color()
+		}
 		return
 	}
 	if g.Wizard {
@@ -954,13 +1034,26 @@ func (ui *gameui) PositionDrawing(p gruid.Point) (r rune, fgColor, bgColor uicol
 		r = '.'
 		if _, ok := g.Fungus[p]; ok && !g.WrongFoliage[p] || !ok && g.WrongFoliage[p] {
 			r = '"'
+		} else if g.WoodenFloor[p] {
+			r = ':'
+		}
+		if l, ok := g.Lights[p]; ok {
+			if l == Brazier {
+				r = '☼'
+			}
+			fgColor = ColorFgLight
 		}
 		if cld, ok := g.Clouds[p]; ok && g.Player.LOS[p] {
 			r = '§'
-			if cld == CloudFire {
+			switch cld {
+			case CloudFire:
 				fgColor = ColorFgWanderingMonster
-			} else if cld == CloudNight {
+			case CloudNight:
 				fgColor = ColorFgSleepingMonster
+			case CloudPoison:
+				fgColor = ColorFgPoisonGas
+			case CloudConfusion:
+				fgColor = ColorFgConfusedMonster
 			}
 		}
 		if c, ok := g.Collectables[p]; ok {
@@ -977,6 +1070,11 @@ func (ui *gameui) PositionDrawing(p gruid.Point) (r rune, fgColor, bgColor uicol
 			if strt == WinStair {
 				fgColor = ColorFgMagicPlace
 				r = 'Δ'
+			} else if strt == UpStair {
+				fgColor = ColorFgPlace
+				r = '<'
+			} else if strt == BranchStair {
+				fgColor = ColorFgMagicPlace
 			} else {
 				fgColor = ColorFgPlace
 			}
@@ -987,22 +1085,54 @@ func (ui *gameui) PositionDrawing(p gruid.Point) (r rune, fgColor, bgColor uicol
 			} else {
 				fgColor = ColorFgMagicPlace
 			}
+		} else if f, ok := g.Features[p]; ok {
+			r = f.Letter()
+			if f.Used() {
+				fgColor = ColorFgPlace
+			} else {
+				fgColor = ColorFgMagicPlace
+			}
+		} else if _, ok := g.Traps[p]; ok && (g.KnownTraps[p] || g.Wizard) {
+			r = '^'
+			fgColor = ColorFgTrap
 		} else if _, ok := g.Simellas[p]; ok {
 			r = '♣'
 			fgColor = ColorFgSimellas
+		} else if g.Keys[p] {
+			r = '{'
+			fgColor = ColorFgCollectable
+		} else if lv, ok := g.Levers[p]; ok {
+			r = '\\'
+			if !lv.Pulled {
+				fgColor = ColorFgMagicPlace
+			}
 		} else if _, ok := g.Doors[p]; ok {
 			r = '+'
 			fgColor = ColorFgPlace
+			switch g.DoorState(p) {
+			case DoorOpen:
+				r = '\''
+			case DoorLocked, DoorBarred:
+				fgColor = ColorFgLockedDoor
+			}
+		}
+		if g.WizardScent && g.Scent[p] > 0 {
+			r = rune('0' + g.ScentAt(p))
+			fgColor = ColorFgWanderingMonster
 		}
 		if (g.Player.LOS[p] || g.Wizard) && !g.WizardMap {
 			m := g.MonsterAt(p)
 			if m.Exists() {
 				r = m.Kind.Letter()
-				if m.Status(MonsLignified) {
+				if m.Ally {
+					fgColor = ColorFgAlly
+				} else if m.Status(StatusLignification) {
 					fgColor = ColorFgLignifiedMonster
-				} else if m.Status(MonsConfused) {
+				} else if m.Status(StatusEnraged) {
+					fgColor = ColorFgEnragedMonster
+				} else if m.Status(StatusConfusion) {
 					fgColor = ColorFgConfusedMonster
-				} else if m.Status(MonsSlow) {
+				} else if m.Status(StatusSlow) {
 					fgColor = ColorFgSlowedMonster
 				} else if m.State == Resting {
 					fgColor = ColorFgSleepingMonster
@@ -1015,6 +1145,9 @@ func (ui *gameui) PositionDrawing(p gruid.Point) (r rune, fgColor, bgColor uicol
 		} else if !g.Wizard && g.Noise[p] {
 			r = '♫'
 			fgColor = ColorFgWanderingMonster
+		} else if hn, ok := g.HeardNoise[p]; ok && !g.Wizard {
+			r = '♫'
+			fgColor = hn.Color()
 		} else if !g.Wizard && g.DreamingMonster[p] {
 			r = '☻'
 			fgColor = ColorFgSleepingMonster
@@ -1032,6 +1165,12 @@ func (ui *gameui) DrawStatusBar(line int) {
 			g.Player.Statuses[StatusFlames] = 0
 		}()
 	}
+	if g.Dark(g.Player.P) {
+		g.Player.Statuses[StatusDark] = 1
+		defer func() {
+			g.Player.Statuses[StatusDark] = 0
+		}()
+	}
 	for st, c := range g.Player.Statuses {
 		if c > 0 {
 			sts = append(sts, st)
@@ -1061,6 +1200,8 @@ func (ui *gameui) DrawStatusBar(line int) {
 	line++
 	if g.Depth == -1 {
 		ui.DrawText("Depth: Out!", BarCol, line)
+	} else if g.Branch != NoBranch {
+		ui.DrawText(fmt.Sprintf("Depth: %d+%d", g.Depth, g.BranchDepth), BarCol, line)
 	} else {
 		ui.DrawText(fmt.Sprintf("Depth: %d", g.Depth), BarCol, line)
 	}
@@ -1102,6 +1243,12 @@ func (ui *gameui) DrawStatusLine() {
 			g.Player.Statuses[StatusFlames] = 0
 		}()
 	}
+	if g.Dark(g.Player.P) {
+		g.Player.Statuses[StatusDark] = 1
+		defer func() {
+			g.Player.Statuses[StatusDark] = 0
+		}()
+	}
 	for st, c := range g.Player.Statuses {
 		if c > 0 {
 			sts = append(sts, st)
@@ -1151,6 +1298,8 @@ func (ui *gameui) DrawStatusLine() {
 	var depth string
 	if g.Depth == -1 {
 		depth = "D: Out! "
+	} else if g.Branch != NoBranch {
+		depth = fmt.Sprintf("D:%d+%d ", g.Depth, g.BranchDepth)
 	} else {
 		depth = fmt.Sprintf("D:%d ", g.Depth)
 	}
@@ -1212,6 +1361,8 @@ func (ui *gameui) LogColor(e logEntry) uicolor {
 		fg = ColorViolet
 	case logError:
 		fg = ColorRed
+	case logCombat:
+		fg = ColorCyan
 	}
 	return fg
 }
@@ -1401,7 +1552,9 @@ func (ui *gameui) DrawPreviousLogs() {
 		bottom = 2
 	}
 	lines := DungeonHeight + bottom
-	nmax := len(g.Log) - lines
+	filter := LogMessages
+	log := g.FilteredLog(filter)
+	nmax := len(log) - lines
 	n := nmax
 loop:
 	for {
@@ -1413,14 +1566,14 @@ loop:
 			n = 0
 		}
 		to := n + lines
-		if to >= len(g.Log) {
-			to = len(g.Log)
+		if to >= len(log) {
+			to = len(log)
 		}
 		for i := 0; i < bottom; i++ {
 			ui.SetCell(DungeonWidth, DungeonHeight+i, '│', ColorFg, ColorBg)
 		}
 		for i := n; i < to; i++ {
-			e := g.Log[i]
+			e := log[i]
 			fguicolor := ui.LogColor(e)
 			ui.ClearLine(i - n)
 			rc := utf8.RuneCountInString(e.String())
@@ -1439,15 +1592,39 @@ loop:
 				ui.DrawColoredText(e.String(), 0, i-n, fguicolor)
 			}
 		}
-		for i := len(g.Log); i < DungeonHeight+bottom; i++ {
+		for i := len(log); i < DungeonHeight+bottom; i++ {
 			ui.ClearLine(i - n)
 		}
 		ui.ClearLine(lines)
-		s := fmt.Sprintf(" half-page up/down (u/d) quit (x) — (%d/%d) \n", len(g.Log)-to, len(g.Log))
+		var s string
+		if GameConfig.VerboseCombatLog {
+			s = fmt.Sprintf(" half-page up/down (u/d) show: %s (v) export (w) quit (x) — (%d/%d) \n", filter, len(log)-to, len(log))
+		} else {
+			s = fmt.Sprintf(" half-page up/down (u/d) quit (x) — (%d/%d) \n", len(log)-to, len(log))
+		}
 		ui.DrawStyledTextLine(s, lines, FooterLine)
 		ui.Flush()
+		in := ui.PollEvent()
+		if GameConfig.VerboseCombatLog {
+			switch in.key {
+			case "v", "V":
+				filter = filter.Next()
+				log = g.FilteredLog(filter)
+				nmax = len(log) - lines
+				n = nmax
+				continue loop
+			case "w", "W":
+				err := g.WriteCombatLog()
+				if err != nil {
+					g.PrintStyled(err.Error(), logError)
+				} else {
+					g.Print("Combat log exported.")
+				}
+				break loop
+			}
+		}
 		var quit bool
-		n, quit = ui.Scroll(n)
+		n, quit = ui.ScrollInput(in, n)
 		if quit {
 			break loop
 		}
@@ -1458,6 +1635,29 @@ func (ui *gameui) DrawMonsterDescription(mons *monster) {
 	s := mons.Kind.Desc()
 	s += " " + fmt.Sprintf("They can hit for up to %d damage.", mons.Kind.BaseAttack())
 	s += " " + fmt.Sprintf("They have around %d HP.", mons.Kind.MaxHP())
+	if mons.Kind.Fearless() {
+		s += " They never flee."
+	} else if mons.Kind.Cowardly() {
+		s += " They flee easily when hurt."
+	}
+	if mons.Kind.Smelling() {
+		s += " They can follow your scent when they lose sight of you."
+	}
+	if !mons.Kind.CanOpenDoors() {
+		s += " They cannot open doors."
+	}
+	switch {
+	case mons.Ally:
+		s += " This one is your ally: it fights your foes and follows you."
+	case mons.State == Fleeing:
+		s += " This one is fleeing, and will only fight back if cornered."
+	case mons.Behaviour != BehaveWander:
+		s += " " + mons.Behaviour.String()
+	}
+	if ui.g.Player.LOS[mons.P] && !mons.Ally {
+		s += " " + mons.IntentDescription(ui.g)
+		s += " " + ui.g.CombatForecast(mons).String()
+	}
 	ui.DrawDescription(s)
 }
 
@@ -1667,6 +1867,89 @@ func (ui *gameui) SelectPotion(ev event) error {
 	}
 }
 
+func (ui *gameui) AptitudeItem(i, lnum int, apt aptitude, fg uicolor) {
+	bg := ui.ListItemBG(i)
+	ui.ClearLineWithColor(lnum, bg)
+	ui.DrawColoredTextOnBG(fmt.Sprintf("%c - %s", rune(i+97), apt), 0, lnum, fg, bg)
+}
+
+// SelectAptitude makes the player choose an aptitude among the given ones.
+// If later is true, cancelling postpones the choice to the next turn.
+func (ui *gameui) SelectAptitude(apts []aptitude, later bool) (aptitude, error) {
+	desc := false
+	for {
+		ui.ClearLine(0)
+		if desc {
+			ui.DrawColoredText("Describe", 0, 0, ColorBlue)
+			col := utf8.RuneCountInString("Describe")
+			ui.DrawText(" which aptitude? (press ? or click here for choice menu)", col, 0)
+		} else {
+			ui.DrawColoredText("Choose", 0, 0, ColorGreen)
+			col := utf8.RuneCountInString("Choose")
+			ui.DrawText(" which aptitude? (press ? or click here for description menu)", col, 0)
+		}
+		for i, apt := range apts {
+			ui.AptitudeItem(i, i+1, apt, ColorFg)
+		}
+		if later {
+			ui.DrawTextLine(" press (x) to choose later (asked again next turn) ", len(apts)+1)
+		} else {
+			ui.DrawTextLine(" press (x) to cancel ", len(apts)+1)
+		}
+		ui.Flush()
+		index, alt, err := ui.Select(len(apts))
+		if alt {
+			desc = !desc
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		ui.AptitudeItem(index, index+1, apts[index], ColorYellow)
+		ui.Flush()
+		time.Sleep(75 * time.Millisecond)
+		if desc {
+			ui.DrawDescription(apts[index].Description())
+			continue
+		}
+		return apts[index], nil
+	}
+}
+
+func (ui *gameui) WareItem(i, lnum int, w ware, fg uicolor) {
+	bg := ui.ListItemBG(i)
+	ui.ClearLineWithColor(lnum, bg)
+	ui.DrawColoredTextOnBG(fmt.Sprintf("%c - %s (%d simellas)", rune(i+97), w, ui.g.WarePrice(w)), 0, lnum, fg, bg)
+}
+
+// SelectWare makes the player choose a ware among the merchant's stock, given
+// by indices. It returns the index in the stock of the chosen ware.
+func (ui *gameui) SelectWare(wares []int) (int, error) {
+	g := ui.g
+	for {
+		ui.ClearLine(0)
+		ui.DrawColoredText("Buy", 0, 0, ColorGreen)
+		col := utf8.RuneCountInString("Buy")
+		ui.DrawText(fmt.Sprintf(" which item? (you have %d simellas)", g.Player.Simellas), col, 0)
+		for i, j := range wares {
+			ui.WareItem(i, i+1, g.MerchantStock[j], ColorFg)
+		}
+		ui.DrawTextLine(" press (x) to cancel ", len(wares)+1)
+		ui.Flush()
+		index, alt, err := ui.Select(len(wares))
+		if alt {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		ui.WareItem(index, index+1, g.MerchantStock[wares[index]], ColorYellow)
+		ui.Flush()
+		time.Sleep(75 * time.Millisecond)
+		return wares[index], nil
+	}
+}
+
 func (ui *gameui) RodItem(i, lnum int, r rod, fg uicolor) {
 	g := ui.g
 	bg := ui.ListItemBG(i)
@@ -1839,6 +2122,7 @@ const (
 	invertLOS
 	toggleLayout
 	toggleTiles
+	toggleCombatLog
 )
 
 func (s setting) String() (text string) {
@@ -1851,6 +2135,8 @@ func (s setting) String() (text string) {
 		text = "Toggle normal/compact layout"
 	case toggleTiles:
 		text = "Toggle Tiles/Ascii display"
+	case toggleCombatLog:
+		text = "Toggle verbose combat log"
 	}
 	return text
 }
@@ -1859,6 +2145,7 @@ var settingsActions = []setting{
 	setKeys,
 	invertLOS,
 	toggleLayout,
+	toggleCombatLog,
 }
 
 func (ui *gameui) ConfItem(i, lnum int, s setting, fg uicolor) {
@@ -1926,6 +2213,17 @@ func (ui *gameui) HandleSettingAction() error {
 		if err != nil {
 			g.Print(err.Error())
 		}
+	case toggleCombatLog:
+		GameConfig.VerboseCombatLog = !GameConfig.VerboseCombatLog
+		err := g.SaveConfig()
+		if err != nil {
+			g.Print(err.Error())
+		}
+		if GameConfig.VerboseCombatLog {
+			g.Print("Verbose combat log enabled.")
+		} else {
+			g.Print("Verbose combat log disabled.")
+		}
 	}
 	return nil
 }
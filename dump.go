@@ -52,7 +52,10 @@ func (g *game) DumpAptitudes() string {
 func (g *game) DumpStatuses() string {
 	sts := sort.StringSlice{}
 	for st, c := range g.Player.Statuses {
-		if c > 0 {
+		switch {
+		case c > 1:
+			sts = append(sts, fmt.Sprintf("%s (%d)", st, c))
+		case c > 0:
 			sts = append(sts, st.String())
 		}
 	}
@@ -114,6 +117,9 @@ func (g *game) Dump() string {
 	if g.Wizard {
 		fmt.Fprintf(buf, "**WIZARD MODE**\n")
 	}
+	if g.Opts.Daily != "" {
+		fmt.Fprintf(buf, "**DAILY CHALLENGE %s**\n", g.Opts.Daily)
+	}
 	if g.Player.HP > 0 && g.Depth == -1 {
 		fmt.Fprintf(buf, "You escaped from Hareka's Underground alive!\n")
 	} else if g.Player.HP <= 0 {
@@ -175,15 +181,24 @@ func (g *game) Dump() string {
 	}
 	fmt.Fprintf(buf, "\n")
 	fmt.Fprintf(buf, "Miscellaneous:\n")
-	fmt.Fprintf(buf, "You collected %d simellas.\n", g.Player.Simellas)
+	g.DumpSimellas(buf)
+	if g.Player.Keys > 0 {
+		fmt.Fprintf(buf, "You have %d unused keys.\n", g.Player.Keys)
+	}
 	fmt.Fprintf(buf, "You killed %d monsters.\n", g.Stats.Killed)
+	g.DumpOtherKills(buf)
 	fmt.Fprintf(buf, "You spent %d turns in the Underground.\n", g.Turn/10)
 	maxDepth := Max(g.Depth, g.ExploredLevels)
 	s := "s"
 	if maxDepth == 1 {
 		s = ""
 	}
-	fmt.Fprintf(buf, "You explored %d level%s out of %d.\n", maxDepth, s, MaxDepth)
+	if g.Opts.Endless {
+		fmt.Fprintf(buf, "You reached depth %d in endless mode.\n", maxDepth)
+	} else {
+		fmt.Fprintf(buf, "You explored %d level%s out of %d.\n", maxDepth, s, MaxDepth)
+	}
+	fmt.Fprintf(buf, "Your score is %d.\n", g.Score())
 	fmt.Fprintf(buf, "\n")
 	fmt.Fprintf(buf, "Last messages:\n")
 	for i := len(g.Log) - 10; i < len(g.Log); i++ {
@@ -196,6 +211,7 @@ func (g *game) Dump() string {
 	fmt.Fprintf(buf, "┌%s┐\n", strings.Repeat("─", DungeonWidth))
 	buf.WriteString(g.DumpDungeon())
 	fmt.Fprintf(buf, "└%s┘\n", strings.Repeat("─", DungeonWidth))
+	fmt.Fprint(buf, g.DumpClouds())
 	fmt.Fprint(buf, "\n")
 	fmt.Fprint(buf, g.DumpedKilledMonsters())
 	fmt.Fprint(buf, "\n")
@@ -206,6 +222,17 @@ func (g *game) Dump() string {
 	return buf.String()
 }
 
+// DumpSimellas writes the number of collected simellas, and how many of them
+// were spent.
+func (g *game) DumpSimellas(w io.Writer) {
+	if g.Stats.SpentSimellas > 0 {
+		fmt.Fprintf(w, "You collected %d simellas, and spent %d of them.\n",
+			g.Player.Simellas+g.Stats.SpentSimellas, g.Stats.SpentSimellas)
+	} else {
+		fmt.Fprintf(w, "You collected %d simellas.\n", g.Player.Simellas)
+	}
+}
+
 func (g *game) DetailedStatistics(w io.Writer) {
 	fmt.Fprintf(w, "\n")
 	fmt.Fprintf(w, "Statistics:\n")
@@ -218,7 +245,14 @@ func (g *game) DetailedStatistics(w io.Writer) {
 	fmt.Fprintf(w, "You endured %d damage.\n", g.Stats.Damage)
 	fmt.Fprintf(w, "You were lucky %d times.\n", g.Stats.TimesLucky)
 	fmt.Fprintf(w, "You activated %d stones.\n", g.Stats.UsedStones)
+	fmt.Fprintf(w, "You bought %d potions, %d projectiles, %d rod recharges, and %d aptitudes.\n",
+		g.Stats.Purchases[WarePotion], g.Stats.Purchases[WareProjectile],
+		g.Stats.Purchases[WareRecharge], g.Stats.Purchases[WareAptitude])
+	fmt.Fprintf(w, "You discovered %d traps and triggered %d.\n", g.Stats.DiscoveredTraps, g.Stats.TriggeredTraps)
+	fmt.Fprintf(w, "Monsters triggered %d traps.\n", g.Stats.MonsterTraps)
 	fmt.Fprintf(w, "There were %d fires.\n", g.Stats.Burns)
+	fmt.Fprintf(w, "Fires burned %d foliage cells, %d doors, and %d wooden floor cells.\n",
+		g.Stats.Burned[FuelFoliage], g.Stats.Burned[FuelDoor], g.Stats.Burned[FuelWood])
 	fmt.Fprintf(w, "There were %d destroyed walls.\n", g.Stats.Digs)
 	fmt.Fprintf(w, "You rested %d times (%d interruptions).\n", g.Stats.Rest, g.Stats.RestInterrupt)
 	fmt.Fprintf(w, "You spent %d%% turns wounded.\n", g.Stats.TWounded*100/(g.Stats.Turns+1))
@@ -228,7 +262,7 @@ func (g *game) DetailedStatistics(w io.Writer) {
 	if g.Player.HP <= 0 {
 		maxDepth++
 	}
-	if maxDepth >= MaxDepth+1 {
+	if maxDepth >= len(g.Stats.DExplPerc) {
 		// should not happen
 		maxDepth = -1
 	}
@@ -327,6 +361,11 @@ func (g *game) DumpDungeon() string {
 				r = '.'
 				if _, ok := g.Fungus[p]; ok {
 					r = '"'
+				} else if g.WoodenFloor[p] {
+					r = ':'
+				}
+				if l, ok := g.Lights[p]; ok && l == Brazier {
+					r = '☼'
 				}
 				if _, ok := g.Clouds[p]; ok && g.Player.LOS[p] {
 					r = '§'
@@ -344,10 +383,19 @@ func (g *game) DumpDungeon() string {
 					}
 				} else if _, ok := g.MagicalStones[p]; ok {
 					r = '_'
+				} else if f, ok := g.Features[p]; ok {
+					r = f.Letter()
 				} else if _, ok := g.Simellas[p]; ok {
 					r = '♣'
+				} else if g.Keys[p] {
+					r = '{'
+				} else if _, ok := g.Levers[p]; ok {
+					r = '\\'
 				} else if _, ok := g.Doors[p]; ok {
 					r = '+'
+					if g.DoorState(p) == DoorOpen {
+						r = '\''
+					}
 				}
 				m := g.MonsterAt(p)
 				if m.Exists() && (g.Player.LOS[m.P] || g.Wizard) {
@@ -363,6 +411,29 @@ func (g *game) DumpDungeon() string {
 	return buf.String()
 }
 
+// DumpClouds describes the clouds in view, if any.
+func (g *game) DumpClouds() string {
+	count := map[cloud]int{}
+	for p, cld := range g.Clouds {
+		if g.Player.LOS[p] {
+			count[cld]++
+		}
+	}
+	if len(count) == 0 {
+		return ""
+	}
+	clds := sort.StringSlice{}
+	for cld, n := range count {
+		clds = append(clds, fmt.Sprintf("- %s (%d cells)", cld, n))
+	}
+	sort.Sort(clds)
+	s := "Clouds in view:\n" + strings.Join(clds, "\n") + "\n"
+	if cld, ok := g.Clouds[g.Player.P]; ok {
+		s += fmt.Sprintf("You are standing in %s.\n", cld)
+	}
+	return s
+}
+
 func (g *game) DumpedKilledMonsters() string {
 	buf := &bytes.Buffer{}
 	fmt.Fprint(buf, "Killed Monsters:\n")
@@ -379,6 +450,9 @@ func (g *game) SimplifedDump(err error) string {
 	if g.Wizard {
 		fmt.Fprintf(buf, "**WIZARD MODE**\n")
 	}
+	if g.Opts.Daily != "" {
+		fmt.Fprintf(buf, "**DAILY CHALLENGE %s**\n", g.Opts.Daily)
+	}
 	if g.Player.HP > 0 && g.Depth == -1 {
 		fmt.Fprintf(buf, "You escaped from Hareka's Underground alive!\n")
 	} else if g.Player.HP <= 0 {
@@ -386,16 +460,31 @@ func (g *game) SimplifedDump(err error) string {
 	} else {
 		fmt.Fprintf(buf, "You are exploring depth %d of Hareka's Underground.\n", g.Depth)
 	}
-	fmt.Fprintf(buf, "You collected %d simellas.\n", g.Player.Simellas)
+	g.DumpSimellas(buf)
 	fmt.Fprintf(buf, "You killed %d monsters.\n", g.Stats.Killed)
+	g.DumpOtherKills(buf)
 	fmt.Fprintf(buf, "You spent %.0f turns in the Underground.\n", float64(g.Turn)/10)
 	maxDepth := Max(g.Depth, g.ExploredLevels)
 	s := "s"
 	if maxDepth == 1 {
 		s = ""
 	}
-	fmt.Fprintf(buf, "You explored %d level%s out of %d.\n", maxDepth, s, MaxDepth+1)
+	if g.Opts.Endless {
+		fmt.Fprintf(buf, "You reached depth %d in endless mode.\n", maxDepth)
+	} else {
+		fmt.Fprintf(buf, "You explored %d level%s out of %d.\n", maxDepth, s, MaxDepth+1)
+	}
+	fmt.Fprintf(buf, "Your score is %d.\n", g.Score())
 	fmt.Fprintf(buf, "\n")
+	if g.Opts.Daily != "" {
+		scores, err := g.DailyScores()
+		if err != nil {
+			fmt.Fprintf(buf, "Error reading daily scores: %v.\n", err)
+		} else {
+			fmt.Fprint(buf, g.DailyLeaderboard(scores))
+		}
+		fmt.Fprintf(buf, "\n")
+	}
 	if err != nil {
 		fmt.Fprintf(buf, "Error writing dump: %v.\n", err)
 	} else {
@@ -412,3 +501,17 @@ func (g *game) SimplifedDump(err error) string {
 	fmt.Fprintf(buf, "───Press (x) to quit───")
 	return buf.String()
 }
+
+// DumpOtherKills writes the number of monsters killed by allies, by other
+// monsters and by the environment, if any.
+func (g *game) DumpOtherKills(buf io.Writer) {
+	if g.Stats.AllyKills > 0 {
+		fmt.Fprintf(buf, "Your allies killed %d monsters.\n", g.Stats.AllyKills)
+	}
+	if g.Stats.InfightingKills > 0 {
+		fmt.Fprintf(buf, "%d monsters were killed by other monsters.\n", g.Stats.InfightingKills)
+	}
+	if g.Stats.EnvironmentKills > 0 {
+		fmt.Fprintf(buf, "%d monsters were killed by the environment.\n", g.Stats.EnvironmentKills)
+	}
+}
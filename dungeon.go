@@ -54,6 +54,10 @@ func (dg dungen) Use(g *game) {
 		g.GenBSPMap(DungeonHeight, DungeonWidth)
 	}
 	g.Dungeon.Gen = dg
+	if g.Branch != NoBranch {
+		return
+	}
+	g.DepthStats()
 	g.Stats.DLayout[g.Depth] = dg.String()
 }
 
@@ -703,7 +707,7 @@ func (g *game) GenRoomMap(h, w int) {
 }
 
 func (g *game) PutDoorsList(doors map[gruid.Point]bool, threshold int) {
-	for p := range doors {
+	for _, p := range sortedPoints(doors) {
 		if g.DoorCandidate(p) && RandInt(100) > threshold {
 			g.Doors[p] = true
 			delete(g.Fungus, p)
@@ -825,7 +829,7 @@ loop:
 	if RandInt(1+rooms) == 0 {
 		w, h := GenLittleRoomSize()
 		i := 0
-		for p := range d.DigIsolatedRoom(w, h) {
+		for _, p := range sortedPoints(d.DigIsolatedRoom(w, h)) {
 			doors[p] = true
 			if i == 0 {
 				d.ConnectIsolatedRoom(p)
@@ -837,7 +841,7 @@ loop:
 	g.Dungeon = d
 	g.Fungus = g.Foliage(DungeonHeight, DungeonWidth)
 	g.PutDoors(5)
-	for p := range doors {
+	for _, p := range sortedPoints(doors) {
 		if g.DoorCandidate(p) && RandInt(100) > 20 {
 			g.Doors[p] = true
 			delete(g.Fungus, p)
@@ -1096,7 +1100,7 @@ loop:
 	if RandInt(5) > 0 {
 		w, h := GenLittleRoomSize()
 		i := 0
-		for p := range d.DigIsolatedRoom(w, h) {
+		for _, p := range sortedPoints(d.DigIsolatedRoom(w, h)) {
 			doors[p] = true
 			if i == 0 {
 				d.ConnectIsolatedRoom(p)
@@ -1106,7 +1110,7 @@ loop:
 		if RandInt(4) == 0 {
 			w, h := GenCaveRoomSize()
 			i := 0
-			for p := range d.DigIsolatedRoom(w, h) {
+			for _, p := range sortedPoints(d.DigIsolatedRoom(w, h)) {
 				doors[p] = true
 				if i == 0 {
 					d.ConnectIsolatedRoom(p)
@@ -1117,7 +1121,7 @@ loop:
 	}
 	g.Dungeon = d
 	g.PutDoors(10)
-	for p := range doors {
+	for _, p := range sortedPoints(doors) {
 		if g.DoorCandidate(p) && RandInt(100) > 20 {
 			g.Doors[p] = true
 			delete(g.Fungus, p)
@@ -1222,7 +1226,7 @@ func (g *game) ExtendEdgeRoom(r room, doors map[gruid.Point]bool) room {
 	if !extend {
 		return r
 	}
-	for p := range doors {
+	for _, p := range sortedPoints(doors) {
 		if p.X == 1 || p.X == DungeonWidth-2 || p.Y == 1 || p.Y == DungeonHeight-2 {
 			delete(g.Doors, p)
 			continue
@@ -1449,7 +1453,7 @@ func (g *game) GenBSPMap(height, width int) {
 		} else {
 			empty++
 		}
-		for p := range doors {
+		for _, p := range sortedPoints(doors) {
 			if g.DoorCandidate(p) && RandInt(100) > 10 {
 				g.Doors[p] = true
 			}
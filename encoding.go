@@ -37,6 +37,7 @@ type config struct {
 	DarkLOS            bool
 	Small              bool
 	Tiles              bool
+	VerboseCombatLog   bool
 	Version            string
 }
 
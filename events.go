@@ -49,22 +49,8 @@ type simpleAction int
 
 const (
 	PlayerTurn simpleAction = iota
-	Teleportation
-	BerserkEnd
-	SlowEnd
-	ExhaustionEnd
-	HasteEnd
-	EvasionEnd
-	LignificationEnd
-	ConfusionEnd
-	NauseaEnd
-	DisabledShieldEnd
-	CorrosionEnd
-	DigEnd
-	SwapEnd
-	ShadowsEnd
-	SlayEnd
-	AccurateEnd
+	StatusEnd
+	StatusTick
 	BlockEnd
 )
 
@@ -87,6 +73,9 @@ func (g *game) PopIEvent() iEvent {
 type simpleEvent struct {
 	ERank   int
 	EAction simpleAction
+	Status  status // for StatusEnd and StatusTick
+	Ticks   int    // remaining ticks, for StatusTick
+	Epoch   int    // status epoch, for StatusEnd and StatusTick
 }
 
 func (sev *simpleEvent) Rank() int {
@@ -106,6 +95,8 @@ func (sev *simpleEvent) Action(g *game) {
 	switch sev.EAction {
 	case PlayerTurn:
 		g.ComputeNoise()
+		g.SearchTraps()
+		g.UpdateScent()
 		g.LogNextTick = g.LogIndex
 		g.AutoNext = g.AutoPlayer(sev)
 		if g.AutoNext {
@@ -117,106 +108,12 @@ func (sev *simpleEvent) Action(g *game) {
 			return
 		}
 		g.TurnStats()
-	case Teleportation:
-		if !g.Player.HasStatus(StatusLignification) {
-			g.Teleportation(sev)
-		} else {
-			g.Print("Lignification has prevented teleportation.")
-		}
-		g.Player.Statuses[StatusTele] = 0
-	case BerserkEnd:
-		g.Player.Statuses[StatusBerserk] = 0
-		g.Player.Statuses[StatusSlow]++
-		g.Player.Statuses[StatusExhausted] = 1
-		g.Player.HP -= int(10 * g.Player.HP / Max(g.Player.HPMax(), g.Player.HP))
-		g.PrintStyled("You are no longer berserk.", logStatusEnd)
-		g.PushEvent(&simpleEvent{ERank: sev.Rank() + 90 + RandInt(30), EAction: SlowEnd})
-		g.PushEvent(&simpleEvent{ERank: sev.Rank() + 270 + RandInt(60), EAction: ExhaustionEnd})
-		g.ui.StatusEndAnimation()
-	case SlowEnd:
-		g.Player.Statuses[StatusSlow]--
-		if g.Player.Statuses[StatusSlow] <= 0 {
-			g.PrintStyled("You no longer feel slow.", logStatusEnd)
-			g.ui.StatusEndAnimation()
-		}
-	case ExhaustionEnd:
-		g.PrintStyled("You no longer feel exhausted.", logStatusEnd)
-		g.Player.Statuses[StatusExhausted] = 0
-		g.ui.StatusEndAnimation()
-	case HasteEnd:
-		g.Player.Statuses[StatusSwift]--
-		if g.Player.Statuses[StatusSwift] == 0 {
-			g.PrintStyled("You no longer feel speedy.", logStatusEnd)
-			g.ui.StatusEndAnimation()
-		}
-	case EvasionEnd:
-		g.Player.Statuses[StatusAgile]--
-		if g.Player.Statuses[StatusAgile] == 0 {
-			g.PrintStyled("You no longer feel agile.", logStatusEnd)
-			g.ui.StatusEndAnimation()
-		}
-	case LignificationEnd:
-		g.Player.Statuses[StatusLignification]--
-		g.Player.HP -= int(10 * g.Player.HP / Max(g.Player.HPMax(), g.Player.HP))
-		if g.Player.Statuses[StatusLignification] == 0 {
-			g.PrintStyled("You no longer feel attached to the ground.", logStatusEnd)
-			g.ui.StatusEndAnimation()
-		}
-	case ConfusionEnd:
-		g.PrintStyled("You no longer feel confused.", logStatusEnd)
-		g.Player.Statuses[StatusConfusion] = 0
-		g.ui.StatusEndAnimation()
-	case NauseaEnd:
-		g.PrintStyled("You no longer feel sick.", logStatusEnd)
-		g.Player.Statuses[StatusNausea] = 0
-		g.ui.StatusEndAnimation()
-	case DisabledShieldEnd:
-		g.PrintStyled("You manage to dislodge the projectile from your shield.", logStatusEnd)
-		g.Player.Statuses[StatusDisabledShield] = 0
-		g.ui.StatusEndAnimation()
-	case CorrosionEnd:
-		g.Player.Statuses[StatusCorrosion]--
-		if g.Player.Statuses[StatusCorrosion] == 0 {
-			g.PrintStyled("Your equipment is now free from acid.", logStatusEnd)
-			g.ui.StatusEndAnimation()
-		}
-	case DigEnd:
-		g.Player.Statuses[StatusDig]--
-		if g.Player.Statuses[StatusDig] == 0 {
-			g.PrintStyled("You no longer feel like an earth dragon.", logStatusEnd)
-			g.ui.StatusEndAnimation()
-		}
-	case SwapEnd:
-		g.Player.Statuses[StatusSwap]--
-		if g.Player.Statuses[StatusSwap] == 0 {
-			g.PrintStyled("You no longer feel light-footed.", logStatusEnd)
-			g.ui.StatusEndAnimation()
-		}
-	case ShadowsEnd:
-		g.Player.Statuses[StatusShadows]--
-		if g.Player.Statuses[StatusShadows] == 0 {
-			g.PrintStyled("The shadows leave you.", logStatusEnd)
-			g.ui.StatusEndAnimation()
-			g.ComputeLOS()
-			g.MakeMonstersAware()
-		}
-	case SlayEnd:
-		if g.Player.Statuses[StatusSlay] <= 0 {
-			break
-		}
-		g.Player.Statuses[StatusSlay]--
-		if g.Player.Statuses[StatusSlay] == 0 {
-			g.PrintStyled("You no longer feel extra slaying power.", logStatusEnd)
-			g.ui.StatusEndAnimation()
-			g.ComputeLOS()
-			g.MakeMonstersAware()
-		}
-	case AccurateEnd:
-		g.Player.Statuses[StatusAccurate]--
-		if g.Player.Statuses[StatusAccurate] == 0 {
-			g.PrintStyled("You no longer feel accurate.", logStatusEnd)
-			g.ui.StatusEndAnimation()
+	case StatusEnd:
+		if sev.Epoch == g.Player.StatusEpoch[sev.Status] {
+			g.ExpireStatus(sev.Status, sev)
 		}
+	case StatusTick:
+		g.TickStatus(sev)
 	case BlockEnd:
 		g.Player.Blocked = false
 	}
@@ -226,16 +123,18 @@ type monsterAction int
 
 const (
 	MonsterTurn monsterAction = iota
-	MonsConfusionEnd
-	MonsExhaustionEnd
-	MonsSlowEnd
-	MonsLignificationEnd
+	MonsStatusEnd
+	MonsStatusTick
 )
 
 type monsterEvent struct {
 	ERank   int
 	NMons   int
 	EAction monsterAction
+	Status  status     // for MonsStatusEnd and MonsStatusTick
+	Ticks   int        // remaining ticks, for MonsStatusTick
+	Source  killSource // source of the status, for MonsStatusTick
+	Epoch   int        // status epoch, for MonsStatusEnd and MonsStatusTick
 }
 
 func (mev *monsterEvent) Rank() int {
@@ -249,40 +148,15 @@ func (mev *monsterEvent) Action(g *game) {
 		if mons.Exists() {
 			mons.HandleTurn(g, mev)
 		}
-	case MonsConfusionEnd:
+	case MonsStatusEnd:
 		mons := g.Monsters[mev.NMons]
-		if mons.Exists() {
-			mons.Statuses[MonsConfused] = 0
-			if g.Player.LOS[mons.P] {
-				g.Printf("The %s is no longer confused.", mons.Kind)
-			}
-			mons.Path = mons.APath(g, mons.P, mons.Target)
+		if mons.Exists() && mev.Epoch == mons.StatusEpoch[mev.Status] {
+			mons.ExpireStatus(g, mev.Status, mev)
 		}
-	case MonsLignificationEnd:
+	case MonsStatusTick:
 		mons := g.Monsters[mev.NMons]
 		if mons.Exists() {
-			mons.Statuses[MonsLignified] = 0
-			if g.Player.LOS[mons.P] {
-				g.Printf("%s is no longer lignified.", mons.Kind.Definite(true))
-			}
-			mons.Path = mons.APath(g, mons.P, mons.Target)
-		}
-	case MonsSlowEnd:
-		mons := g.Monsters[mev.NMons]
-		if mons.Exists() {
-			mons.Statuses[MonsSlow]--
-			if g.Player.LOS[mons.P] {
-				g.Printf("%s is no longer slowed.", mons.Kind.Definite(true))
-			}
-		}
-	case MonsExhaustionEnd:
-		mons := g.Monsters[mev.NMons]
-		if mons.Exists() {
-			mons.Statuses[MonsExhausted]--
-			//if mons.State != Resting && g.Player.LOS[mons.Pos] &&
-			//(mons.Kind.Ranged() || mons.Kind.Smiting()) && mons.Pos.Distance(g.Player.Pos) > 1 {
-			//g.Printf("%s is ready to fire again.", mons.Kind.Definite(true))
-			//}
+			mons.TickStatus(g, mev)
 		}
 	}
 }
@@ -300,12 +174,15 @@ const (
 	ObstructionProgression
 	FireProgression
 	NightProgression
+	GasProgression
 )
 
 type cloudEvent struct {
 	ERank   int
 	P       gruid.Point
 	EAction cloudAction
+	Density int
+	Burning int
 }
 
 func (cev *cloudEvent) Rank() int {
@@ -327,6 +204,7 @@ func (cev *cloudEvent) Action(g *game) {
 			break
 		}
 		g.Dungeon.SetCell(cev.P, FreeCell)
+		g.InvalidatePathMaps()
 		g.MakeNoise(TemporalWallNoise, cev.P)
 		g.Fog(cev.P, 1, &simpleEvent{ERank: cev.Rank()})
 		g.ComputeLOS()
@@ -343,17 +221,16 @@ func (cev *cloudEvent) Action(g *game) {
 			break
 		}
 		g.BurnCreature(cev.P, cev)
-		if RandInt(10) == 0 {
+		cev.Burning--
+		if cev.Burning <= 0 {
 			delete(g.Clouds, cev.P)
 			g.Fog(cev.P, 1, &simpleEvent{ERank: cev.Rank()})
 			g.ComputeLOS()
 			break
 		}
-		for _, p := range g.Dungeon.FreeNeighbors(cev.P) {
-			if RandInt(3) > 0 {
-				continue
-			}
-			g.Burn(p, cev)
+		g.SpreadFire(cev.P, cev)
+		if RandInt(3) == 0 {
+			g.EmitSmoke(cev.P, cev)
 		}
 		cev.Renew(g, 10)
 	case NightProgression:
@@ -367,18 +244,19 @@ func (cev *cloudEvent) Action(g *game) {
 			break
 		}
 		cev.Renew(g, 10)
+	case GasProgression:
+		cev.GasProgression(g)
 	}
 }
 
 func (g *game) MakeCreatureSleep(p gruid.Point, ev event) {
 	if p == g.Player.P {
-		g.Player.Statuses[StatusSlow]++
-		g.PushEvent(&simpleEvent{ERank: ev.Rank() + 30 + RandInt(10), EAction: SlowEnd})
+		g.PutStatus(StatusSlow, ev)
 		g.Print("The clouds of night make you sleepy.")
 		return
 	}
 	mons := g.MonsterAt(p)
-	if !mons.Exists() || (RandInt(2) == 0 && mons.Status(MonsExhausted)) {
+	if !mons.Exists() || (RandInt(2) == 0 && mons.Status(StatusExhausted)) {
 		// do not always make already exhausted monsters sleep (they were probably awaken)
 		return
 	}
@@ -397,7 +275,7 @@ func (g *game) BurnCreature(p gruid.Point, ev event) {
 			if g.Player.LOS[mons.P] {
 				g.PrintfStyled("%s is killed by the fire.", logPlayerHit, mons.Kind.Definite(true))
 			}
-			g.HandleKill(mons, ev)
+			g.HandleKill(mons, KillByEnvironment, ev)
 		} else {
 			mons.MakeAwareIfHurt(g)
 		}
@@ -420,30 +298,36 @@ func (g *game) Burn(p gruid.Point, ev event) {
 	if _, ok := g.Clouds[p]; ok {
 		return
 	}
-	_, okFungus := g.Fungus[p]
-	_, okDoor := g.Doors[p]
-	if !okFungus && !okDoor {
+	f, ok := g.Fuel(p)
+	if !ok {
 		return
 	}
 	g.Stats.Burns++
-	foliage := true
-	delete(g.Fungus, p)
-	if _, ok := g.Doors[p]; ok {
+	g.Stats.Burned[f]++
+	switch f {
+	case FuelFoliage:
+		delete(g.Fungus, p)
+		g.RemoveLight(p)
+	case FuelDoor:
 		delete(g.Doors, p)
-		foliage = false
+		delete(g.DoorStates, p)
 		g.Print("The door vanishes in flames.")
+	case FuelWood:
+		delete(g.WoodenFloor, p)
 	}
 	g.Clouds[p] = CloudFire
+	g.InvalidatePathMaps()
 	if !g.Player.LOS[p] {
-		if foliage {
+		switch f {
+		case FuelFoliage:
 			g.WrongFoliage[p] = true
-		} else {
+		case FuelDoor:
 			g.WrongDoor[p] = true
 		}
 	} else {
 		g.ComputeLOS()
 	}
-	g.PushEvent(&cloudEvent{ERank: ev.Rank() + 10, EAction: FireProgression, P: p})
+	g.PushEvent(&cloudEvent{ERank: ev.Rank() + 10, EAction: FireProgression, P: p, Burning: f.BurningTurns()})
 	g.BurnCreature(p, ev)
 }
 
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"codeberg.org/anaseto/gruid"
+)
+
+// feature is an interactive dungeon feature. Each feature can be used only
+// once, after which it becomes inert.
+type feature int
+
+const (
+	Fountain feature = iota
+	DryFountain
+	Altar
+	ColdAltar
+	Shrine
+	AbandonedShrine
+	Merchant
+)
+
+func (f feature) String() (text string) {
+	switch f {
+	case Fountain:
+		text = "fountain"
+	case DryFountain:
+		text = "dry fountain"
+	case Altar:
+		text = "altar"
+	case ColdAltar:
+		text = "cold altar"
+	case Shrine:
+		text = "shrine"
+	case AbandonedShrine:
+		text = "abandoned shrine"
+	case Merchant:
+		text = "merchant"
+	}
+	return text
+}
+
+func (f feature) Description() (text string) {
+	switch f {
+	case Fountain:
+		text = "The water of this fountain has strange properties. Drinking from it may have a good or a bad effect."
+	case DryFountain:
+		text = "This fountain has dried up."
+	case Altar:
+		text = "You can offer some simellas on this altar to ask for a boon. The offering counts against your score."
+	case ColdAltar:
+		text = "This altar has already received an offering, and will not answer anymore."
+	case Shrine:
+		text = "Praying at this shrine will let you choose a new aptitude among a few ones."
+	case AbandonedShrine:
+		text = "The power of this shrine has been spent."
+	case Merchant:
+		text = "A wandering merchant trades potions, projectiles, rod recharges and aptitude teachings for simellas. Spent simellas do not count anymore for your score."
+	}
+	text += " You can use a feature by standing on it and pressing the interact key."
+	return text
+}
+
+func (f feature) Letter() rune {
+	switch f {
+	case Fountain, DryFountain:
+		return '~'
+	case Altar, ColdAltar:
+		return '='
+	case Merchant:
+		return '$'
+	default:
+		return 'Φ'
+	}
+}
+
+// Used reports whether the feature has already been used.
+func (f feature) Used() bool {
+	switch f {
+	case DryFountain, ColdAltar, AbandonedShrine:
+		return true
+	}
+	return false
+}
+
+// GenFeatures places some fountains, altars and shrines in the level, and a
+// merchant in the merchant level.
+func (g *game) GenFeatures() {
+	g.Features = map[gruid.Point]feature{}
+	g.MerchantStock = nil
+	if g.Branch == NoBranch && g.Depth == g.Opts.MerchantLevel {
+		g.Features[g.FreeCellForStatic()] = Merchant
+		g.GenMerchantStock()
+	}
+	if RandInt(2) == 0 {
+		g.Features[g.FreeCellForStatic()] = Fountain
+	}
+	if g.Depth > 1 && RandInt(3) == 0 {
+		g.Features[g.FreeCellForStatic()] = Altar
+	}
+	if g.Depth > 2 && RandInt(6) == 0 {
+		g.Features[g.FreeCellForStatic()] = Shrine
+	}
+}
+
+// AltarCost returns the number of simellas required for an offering at an
+// altar in the current level.
+func (g *game) AltarCost() int {
+	return 5 + 2*g.DangerDepth()
+}
+
+// UseFeature uses the feature at the player's position.
+func (g *game) UseFeature(ev event) error {
+	p := g.Player.P
+	f, ok := g.Features[p]
+	if !ok {
+		return errors.New("There is nothing to interact with here.")
+	}
+	if f.Used() {
+		return fmt.Errorf("Nothing happens: this is %s.", Indefinite(f.String(), false))
+	}
+	var err error
+	switch f {
+	case Merchant:
+		return g.Trade(ev)
+	case Fountain:
+		g.DrinkFountain(ev)
+		g.Features[p] = DryFountain
+	case Altar:
+		err = g.MakeOffering(ev)
+		if err == nil {
+			g.Features[p] = ColdAltar
+		}
+	case Shrine:
+		err = g.Pray(ev)
+		if err == nil {
+			g.Features[p] = AbandonedShrine
+		}
+	}
+	if err != nil {
+		return err
+	}
+	g.StoryPrintf("Used %s.", Indefinite(f.String(), false))
+	ev.Renew(g, 10)
+	return nil
+}
+
+// DrinkFountain applies a random effect of the fountain's water.
+func (g *game) DrinkFountain(ev event) {
+	switch RandInt(6) {
+	case 0:
+		g.Player.HP = g.Player.HPMax()
+		g.Print("You drink from the fountain. The water is refreshing.")
+	case 1:
+		g.Player.MP = g.Player.MPMax()
+		g.Print("You drink from the fountain. You feel your magic replenished.")
+	case 2:
+		g.Print("You drink from the fountain. You feel quick.")
+		g.PutStatus(StatusSwift, ev)
+	case 3:
+		g.Print("You drink from the fountain. The water tastes foul.")
+		g.Poison(ev)
+	case 4:
+		g.Print("You drink from the fountain. The water makes your head spin.")
+		g.Confusion(ev)
+	default:
+		g.Print("You drink from the fountain. The water tastes of minerals.")
+	}
+	g.Print("The fountain dries up.")
+}
+
+// MakeOffering sacrifices some simellas at an altar in exchange for a random
+// boon.
+func (g *game) MakeOffering(ev event) error {
+	cost := g.AltarCost()
+	if g.Player.Simellas < cost {
+		return fmt.Errorf("You need %d simellas to make an offering.", cost)
+	}
+	if !g.ui.OfferingConfirmation(cost) {
+		return errors.New(DoNothing)
+	}
+	g.Player.Simellas -= cost
+	g.Stats.SpentSimellas += cost
+	g.StoryPrintf("Offered %d simellas at an altar.", cost)
+	boons := []func(){
+		func() {
+			pt := potion(RandInt(NumPotions))
+			g.Player.Consumables[pt]++
+			g.Printf("%s appears on the altar. You take it.", Indefinite(pt.String(), true))
+		},
+		func() {
+			g.Player.HP = g.Player.HPMax()
+			g.CureStatuses()
+			g.Print("You feel healed.")
+		},
+	}
+	if len(g.Player.Rods) > 0 {
+		boons = append(boons, func() {
+			g.RefillRods()
+			g.Print("Your rods are fully recharged.")
+		})
+	}
+	if g.Player.Bored > 0 {
+		boons = append(boons, func() {
+			g.Player.Bored = 0
+			g.Boredom = 0
+			g.Print("You feel less bored.")
+		})
+	}
+	g.PrintfStyled("You offer %d simellas at the altar.", logSpecial, cost)
+	boons[RandInt(len(boons))]()
+	return nil
+}
+
+// RefillRods fully recharges the player's rods.
+func (g *game) RefillRods() {
+	for r, props := range g.Player.Rods {
+		props.Charge = r.MaxCharge()
+		if g.Player.Armour == CelmistRobe {
+			props.Charge += 2
+		}
+		g.Player.Rods[r] = props
+	}
+}
+
+// RodsCharged reports whether all the player's rods are fully charged.
+func (g *game) RodsCharged() bool {
+	for r, props := range g.Player.Rods {
+		max := r.MaxCharge()
+		if g.Player.Armour == CelmistRobe {
+			max += 2
+		}
+		if props.Charge < max {
+			return false
+		}
+	}
+	return true
+}
+
+// Pray makes the player choose a new aptitude at a shrine.
+func (g *game) Pray(ev event) error {
+	apts := g.OfferedAptitudes(3)
+	if len(apts) == 0 {
+		return errors.New("You pray, but the shrine has nothing more to teach you.")
+	}
+	apt, err := g.ui.SelectAptitude(apts, false)
+	if err != nil {
+		return err
+	}
+	g.ApplyAptitude(apt)
+	g.StoryPrintf("Gained aptitude: %s", apt)
+	return nil
+}
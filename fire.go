@@ -0,0 +1,118 @@
+package main
+
+import "codeberg.org/anaseto/gruid"
+
+// fuel is a kind of flammable terrain feature.
+type fuel int
+
+const (
+	FuelFoliage fuel = iota
+	FuelDoor
+	FuelWood
+)
+
+const NumFuels = int(FuelWood) + 1
+
+func (f fuel) String() (text string) {
+	switch f {
+	case FuelFoliage:
+		text = "foliage"
+	case FuelDoor:
+		text = "door"
+	case FuelWood:
+		text = "wooden floor"
+	}
+	return text
+}
+
+// Flammability returns the chance, in percent, that the feature catches fire
+// each turn from an adjacent fire.
+func (f fuel) Flammability() int {
+	switch f {
+	case FuelFoliage:
+		return 35
+	case FuelDoor:
+		return 20
+	case FuelWood:
+		return 60
+	}
+	return 0
+}
+
+// BurningTurns returns a random number of turns during which the feature
+// burns.
+func (f fuel) BurningTurns() int {
+	switch f {
+	case FuelFoliage:
+		return 4 + RandInt(6)
+	case FuelDoor:
+		return 8 + RandInt(8)
+	case FuelWood:
+		return 3 + RandInt(4)
+	}
+	return 1
+}
+
+// Fuel returns the flammable feature at a given position, if any.
+func (g *game) Fuel(p gruid.Point) (fuel, bool) {
+	if g.Doors[p] {
+		return FuelDoor, true
+	}
+	if _, ok := g.Fungus[p]; ok {
+		return FuelFoliage, true
+	}
+	if g.WoodenFloor[p] {
+		return FuelWood, true
+	}
+	return 0, false
+}
+
+// SpreadFire gives a chance to each flammable neighbor of a burning position
+// to catch fire, depending on its flammability.
+func (g *game) SpreadFire(p gruid.Point, ev event) {
+	for _, q := range g.Dungeon.FreeNeighbors(p) {
+		f, ok := g.Fuel(q)
+		if !ok || RandInt(100) >= f.Flammability() {
+			continue
+		}
+		g.Burn(q, ev)
+	}
+}
+
+// EmitSmoke makes some smoke come out of the fire at a given position, on a
+// random non flammable neighbor.
+func (g *game) EmitSmoke(p gruid.Point, ev event) {
+	ps := []gruid.Point{}
+	for _, q := range g.Dungeon.FreeNeighbors(p) {
+		if _, ok := g.Clouds[q]; ok {
+			continue
+		}
+		if _, ok := g.Fuel(q); ok {
+			continue
+		}
+		ps = append(ps, q)
+	}
+	if len(ps) == 0 {
+		return
+	}
+	q := ps[RandInt(len(ps))]
+	g.Clouds[q] = CloudFog
+	g.PushEvent(&cloudEvent{ERank: ev.Rank() + 20 + RandInt(30), EAction: CloudEnd, P: q})
+	g.ComputeLOS()
+}
+
+// GenWoodenFloor places a few patches of flammable wooden floor.
+func (g *game) GenWoodenFloor() {
+	g.WoodenFloor = map[gruid.Point]bool{}
+	npatches := RandInt(3)
+	for i := 0; i < npatches; i++ {
+		dij := &normalPath{game: g}
+		nodes := g.PR.BreadthFirstMap(dij, []gruid.Point{g.FreeCellForStatic()}, 1+RandInt(2))
+		for _, n := range nodes {
+			if _, ok := g.Fungus[n.P]; ok || g.Doors[n.P] {
+				continue
+			}
+			g.WoodenFloor[n.P] = true
+		}
+	}
+}
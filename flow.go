@@ -0,0 +1,160 @@
+package main
+
+import (
+	"codeberg.org/anaseto/gruid"
+	"codeberg.org/anaseto/gruid/paths"
+)
+
+// flowCache holds dijkstra maps toward monster targets. They are shared by
+// all the monsters moving toward the same target during a player turn, so
+// that hunting monsters do not each compute their own path.
+type flowCache struct {
+	dungeon *dungeon
+	turn    int
+	maps    map[flowKey]*paths.PathRange
+	free    []*paths.PathRange   // path ranges to recycle
+	noise   map[gruid.Point]bool // noise positions attracting monsters
+	nbs     paths.Neighbors
+}
+
+// flowKey identifies a flow map: monsters that cannot open doors need their
+// own maps.
+type flowKey struct {
+	to    gruid.Point
+	doors bool // whether closed doors can be opened
+}
+
+// flowPath is used for flow maps. Doors and fire are handled as in monPath,
+// but monsters are not taken into account, as they move during the turn.
+// Maps are computed from the target, so costs apply to the from position.
+type flowPath struct {
+	nbs   paths.Neighbors
+	game  *game
+	doors bool
+}
+
+func (fp *flowPath) Neighbors(p gruid.Point) []gruid.Point {
+	g := fp.game
+	keep := func(np gruid.Point) bool {
+		return valid(np) && g.Dungeon.Cell(np).T != WallCell && !g.DoorBlocks(np, fp.doors)
+	}
+	return fp.nbs.All(p, keep)
+}
+
+func (fp *flowPath) Cost(from, to gruid.Point) int {
+	if cld, ok := fp.game.Clouds[from]; ok && cld == CloudFire {
+		return FirePathCost
+	}
+	return 1
+}
+
+// FlowCache returns the flow cache for the current level and turn.
+func (g *game) FlowCache() *flowCache {
+	fc := g.flow
+	if fc == nil {
+		fc = &flowCache{}
+		g.flow = fc
+	}
+	if fc.dungeon != g.Dungeon {
+		fc.dungeon = g.Dungeon
+		fc.noise = map[gruid.Point]bool{}
+		fc.turn = -1
+	}
+	if fc.turn != g.Stats.Turns {
+		fc.turn = g.Stats.Turns
+		for _, pr := range fc.maps {
+			fc.free = append(fc.free, pr)
+		}
+		fc.maps = map[flowKey]*paths.PathRange{}
+	}
+	return fc
+}
+
+// InvalidatePathMaps records a change affecting paths: the autoexplore map
+// has to be rebuilt, and flow maps computed this turn are discarded.
+func (g *game) InvalidatePathMaps() {
+	g.DijkstraMapRebuild = true
+	if g.flow != nil {
+		g.flow.turn = -1
+	}
+}
+
+// FlowMap returns a dijkstra map toward a target for monsters that can open
+// doors or not, computing it if it was not already done this turn.
+func (g *game) FlowMap(to gruid.Point, doors bool) *paths.PathRange {
+	fc := g.FlowCache()
+	key := flowKey{to: to, doors: doors}
+	if pr, ok := fc.maps[key]; ok {
+		return pr
+	}
+	var pr *paths.PathRange
+	if n := len(fc.free); n > 0 {
+		pr = fc.free[n-1]
+		fc.free = fc.free[:n-1]
+	} else {
+		pr = paths.NewPathRange(gruid.NewRange(0, 0, DungeonWidth, DungeonHeight))
+	}
+	pr.DijkstraMap(&flowPath{game: g, doors: doors}, []gruid.Point{to}, unreachable)
+	fc.maps[key] = pr
+	return pr
+}
+
+// AddNoiseTarget records a noise position that monsters may go to, so that
+// they share a flow map toward it.
+func (g *game) AddNoiseTarget(p gruid.Point) {
+	g.FlowCache().noise[p] = true
+}
+
+// FlowPathing reports whether the monster's movement should use a shared
+// flow map instead of its own path.
+func (m *monster) FlowPathing(g *game) bool {
+	if m.Status(StatusConfusion) || m.Kind == MonsEarthDragon {
+		return false
+	}
+	switch m.State {
+	case Hunting:
+		return true
+	case Wandering:
+		return m.Target == g.Player.P || g.FlowCache().noise[m.Target]
+	}
+	return false
+}
+
+// FlowPath returns a path from a position to a target by following the
+// target's flow map. Free cells are preferred for the first step.
+func (m *monster) FlowPath(g *game, from, to gruid.Point) []gruid.Point {
+	fp := &flowPath{game: g, doors: m.Kind.CanOpenDoors()}
+	pr := g.FlowMap(to, fp.doors)
+	c := pr.DijkstraMapAt(from)
+	if c > unreachable {
+		return nil
+	}
+	fc := g.FlowCache()
+	path := []gruid.Point{from}
+	p := from
+	for c > 0 {
+		next := InvalidPos
+		nc := c
+		for _, q := range fc.nbs.All(p, valid) {
+			qc := pr.DijkstraMapAt(q)
+			if qc+fp.Cost(q, p) != c {
+				// not on a shortest path
+				continue
+			}
+			if !valid(next) {
+				next, nc = q, qc
+			}
+			if p != from || !g.MonsterAt(q).Exists() {
+				next, nc = q, qc
+				break
+			}
+		}
+		if !valid(next) {
+			return nil
+		}
+		path = append(path, next)
+		p = next
+		c = nc
+	}
+	return path
+}
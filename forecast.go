@@ -0,0 +1,178 @@
+package main
+
+import "fmt"
+
+// combatForecast summarizes the expected outcome of melee between the player
+// and a monster. It uses the same formulas as HitMonster and HitPlayer, but
+// enumerates the rolls instead of drawing them.
+type combatForecast struct {
+	HitChance          float64 // player's chance to hit the monster
+	ExpectedDamage     float64 // player's expected damage on hit
+	MaxDamage          int
+	MonsHitChance      float64 // monster's chance to hit the player
+	MonsExpectedDamage float64 // monster's expected damage on hit
+	MonsMaxDamage      int
+	TurnsToKill        int // turns for the player to kill the monster, -1 if never
+	TurnsToDie         int // turns for the monster to kill the player, -1 if never
+}
+
+// rollChance returns the probability that win(x, y) holds for x and y drawn
+// with RandInt(n) and RandInt(m).
+func rollChance(n, m int, win func(x, y int) bool) float64 {
+	n, m = Max(n, 1), Max(m, 1)
+	count := 0
+	for x := 0; x < n; x++ {
+		for y := 0; y < m; y++ {
+			if win(x, y) {
+				count++
+			}
+		}
+	}
+	return float64(count) / float64(n*m)
+}
+
+// MeanDamage returns the expected damage of a distribution returned by
+// DamageDistribution.
+func MeanDamage(dist []float64) float64 {
+	mean := 0.0
+	for dmg, p := range dist {
+		mean += float64(dmg) * p
+	}
+	return mean
+}
+
+// diceDistribution returns the probability distribution of the sum of
+// RandInt(n) for each n in dice.
+func diceDistribution(dice []int) []float64 {
+	dist := []float64{1}
+	for _, n := range dice {
+		n = Max(n, 1)
+		next := make([]float64, len(dist)+n-1)
+		for s, p := range dist {
+			for r := 0; r < n; r++ {
+				next[s+r] += p / float64(n)
+			}
+		}
+		dist = next
+	}
+	return dist
+}
+
+// PlayerHitChance returns the player's chance to hit a monster, taking into
+// account accuracy streaks as in HitMonster.
+func (g *game) PlayerHitChance(mons *monster) float64 {
+	if g.Player.HasStatus(StatusAccurate) {
+		return 1
+	}
+	maxacc := g.PlayerAccuracy(mons)
+	chance := 0.0
+	for x := 0; x < Max(maxacc, 1); x++ {
+		sign, n := AccuracyStreak(x, maxacc, g.Player.AccScore)
+		chance += rollChance(n, mons.Evasion, func(r, ev int) bool {
+			return x+sign*r > mons.EvasionRoll(ev)
+		})
+	}
+	return chance / float64(Max(maxacc, 1))
+}
+
+// CombatForecast computes the melee forecast between the player and a
+// monster.
+func (g *game) CombatForecast(mons *monster) combatForecast {
+	cf := combatForecast{}
+	cf.HitChance = g.PlayerHitChance(mons)
+	dt, base := g.PlayerMeleeAttack(mons)
+	bonus, dice := g.PlayerAttackBonusDice(mons)
+	armor := mons.DefenseArmor()
+	factor := g.SneakAttackFactor(mons)
+	bonuses := diceDistribution(dice)
+	for b, p := range bonuses {
+		cf.ExpectedDamage += p * MeanDamage(DamageDistribution(dt, base+bonus+b, armor))
+	}
+	cf.ExpectedDamage *= float64(factor)
+	cf.MaxDamage = factor * (base + bonus + len(bonuses) - 1)
+	if g.Player.Weapon == FinalBlade && mons.HP <= mons.HPmax/2 {
+		cf.ExpectedDamage = float64(mons.HP)
+		cf.MaxDamage = mons.HP
+	}
+	cf.MonsHitChance, cf.MonsExpectedDamage = g.MonsterHitForecast(mons)
+	cf.MonsMaxDamage = mons.Attack
+	cf.TurnsToKill = turnsToKill(mons.HP, cf.HitChance, cf.ExpectedDamage, g.ActionDelay(10))
+	mdelay := mons.AttackDelay()
+	cf.TurnsToDie = turnsToKill(g.Player.HP, cf.MonsHitChance, cf.MonsExpectedDamage, mdelay)
+	return cf
+}
+
+// MonsterHitForecast returns the chance of a monster's melee attack to hit
+// the player, and its expected damage on hit. Like HitPlayer, it takes into
+// account the dramatic adjustment of potentially deadly attacks.
+func (g *game) MonsterHitForecast(mons *monster) (chance, damage float64) {
+	hp := g.Player.HP
+	dist := DamageDistribution(DmgPhysical, mons.Attack, g.Player.Armor())
+	deadly := 0.0
+	for dmg := hp; dmg < len(dist); dmg++ {
+		deadly += dist[dmg]
+	}
+	hit := rollChance(mons.Accuracy, g.Player.Evasion(), func(acc, ev int) bool {
+		return acc > ev
+	})
+	// deadly attacks reroll evasion once and keep the best roll
+	dramaticHit := 0.0
+	for ev := 0; ev < Max(g.Player.Evasion(), 1); ev++ {
+		dramaticHit += rollChance(mons.Accuracy, g.Player.Evasion(), func(acc, ev2 int) bool {
+			return acc > Max(ev, ev2)
+		})
+	}
+	dramaticHit /= float64(Max(g.Player.Evasion(), 1))
+	for dmg, p := range dist {
+		if dmg >= hp {
+			// deadly damage is rerolled half of the time
+			p /= 2
+		}
+		p += deadly / 2 * dist[dmg]
+		if dmg >= hp {
+			p *= dramaticHit
+		} else {
+			p *= hit
+		}
+		chance += p
+		damage += p * float64(dmg)
+	}
+	if chance > 0 {
+		damage /= chance
+	}
+	if g.CanBlock() {
+		chance *= 1 - rollChance(g.Player.Block(), mons.Accuracy, func(block, acc int) bool {
+			return block >= acc
+		})
+	}
+	return chance, damage
+}
+
+// turnsToKill returns the estimated number of turns needed to inflict some
+// damage, given the chance to hit, the expected damage per hit and the delay
+// between attacks, or -1 if it is not possible.
+func turnsToKill(hp int, chance, damage float64, delay int) int {
+	perAttack := chance * damage
+	if perAttack <= 0 {
+		return -1
+	}
+	attacks := int(float64(hp)/perAttack + 0.999)
+	return Max(1, attacks*delay/10)
+}
+
+func forecastTurns(turns int) string {
+	switch turns {
+	case -1:
+		return "never"
+	case 1:
+		return "1 turn"
+	default:
+		return fmt.Sprintf("%d turns", turns)
+	}
+}
+
+func (cf combatForecast) String() string {
+	return fmt.Sprintf("Melee forecast: you hit %d%% of the time for %.1f damage on average (max %d), and would kill it in about %s. It hits you %d%% of the time for %.1f damage on average (max %d), and would kill you in about %s.",
+		int(100*cf.HitChance+0.5), cf.ExpectedDamage, cf.MaxDamage, forecastTurns(cf.TurnsToKill),
+		int(100*cf.MonsHitChance+0.5), cf.MonsExpectedDamage, cf.MonsMaxDamage, forecastTurns(cf.TurnsToDie))
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"container/heap"
 	"fmt"
+	"sort"
 
 	"codeberg.org/anaseto/gruid"
 	"codeberg.org/anaseto/gruid/paths"
@@ -22,6 +23,9 @@ type game struct {
 	EventIndex          int
 	Depth               int
 	ExploredLevels      int
+	Levels              map[levelID]*level // stored levels (see ChangeLevel)
+	Branch              branch             // current side branch, if any
+	BranchDepth         int                // depth in the current branch
 	DepthPlayerTurn     int
 	Turn                int
 	Highlight           map[gruid.Point]bool // highlighted positions (e.g. targeted ray)
@@ -34,8 +38,21 @@ type game struct {
 	Clouds              map[gruid.Point]cloud
 	Fungus              map[gruid.Point]vegetation
 	Doors               map[gruid.Point]bool
+	Features            map[gruid.Point]feature
+	MerchantStock       []ware
+	AptitudeChoices     [][]aptitude // pending choices of new aptitudes, oldest first
+	DoorStates          map[gruid.Point]doorState
+	Keys                map[gruid.Point]bool
+	Levers              map[gruid.Point]lever
+	Vault               map[gruid.Point]bool
+	WoodenFloor         map[gruid.Point]bool
+	Darkness            map[gruid.Point]bool
+	Lights              map[gruid.Point]light
+	Lighted             map[gruid.Point]bool
 	TemporalWalls       map[gruid.Point]bool
 	MagicalStones       map[gruid.Point]stone
+	Traps               map[gruid.Point]trap
+	KnownTraps          map[gruid.Point]bool
 	GeneratedUniques    map[monsterBand]int
 	GeneratedEquipables map[equipable]bool
 	GeneratedRods       map[rod]bool
@@ -47,6 +64,8 @@ type game struct {
 	WrongDoor           map[gruid.Point]bool
 	ExclusionsMap       map[gruid.Point]bool
 	Noise               map[gruid.Point]bool
+	HeardNoise          map[gruid.Point]heardNoise
+	Scent               map[gruid.Point]int
 	DreamingMonster     map[gruid.Point]bool
 	Resting             bool
 	RestingTurns        int
@@ -55,6 +74,7 @@ type game struct {
 	Targeting           gruid.Point
 	PR                  *paths.PathRange
 	PRauto              *paths.PathRange
+	flow                *flowCache // shared monster flow maps (not saved)
 	AutoTarget          gruid.Point
 	AutoDir             direction
 	AutoHalt            bool
@@ -65,22 +85,31 @@ type game struct {
 	Log                 []logEntry
 	LogIndex            int
 	LogNextTick         int
+	combatLog           []logEntry // verbose combat log (not saved)
 	InfoEntry           string
 	Stats               stats
 	Boredom             int
 	Quit                bool
 	Wizard              bool
 	WizardMap           bool
+	WizardScent         bool
 	Version             string
 	Opts                startOpts
 	ui                  *gameui
 }
 
 type startOpts struct {
-	Alternate     monsterKind
-	StoneLevel    int
-	SpecialBands  map[int][]monsterBandData
-	UnstableLevel int
+	Alternate       monsterKind
+	StoneLevel      int
+	SpecialBands    map[int][]monsterBandData
+	UnstableLevel   int
+	Daily           string         // date of the daily challenge, if any
+	Endless         bool           // levels continue past MaxDepth
+	Revisit         bool           // up stairs lead back to previous levels
+	Branches        map[int]branch // side branch entered from a given depth
+	MerchantLevel   int            // depth of the level with a merchant, if any
+	RandomAptitudes bool           // aptitudes are gained randomly instead of chosen
+	StartAptitude   bool           // an aptitude is gained at the start of the game
 }
 
 func (g *game) FreeCell() gruid.Point {
@@ -105,6 +134,9 @@ func (g *game) FreeCell() gruid.Point {
 		if mons.Exists() {
 			continue
 		}
+		if g.Vault[p] {
+			continue
+		}
 		return p
 	}
 }
@@ -187,6 +219,12 @@ func (g *game) FreeCellForStatic() gruid.Point {
 		if _, ok := g.MagicalStones[p]; ok {
 			continue
 		}
+		if _, ok := g.Features[p]; ok {
+			continue
+		}
+		if _, ok := g.Traps[p]; ok {
+			continue
+		}
 		return p
 	}
 }
@@ -272,6 +310,10 @@ const (
 
 func (g *game) GenDungeon() {
 	g.Fungus = make(map[gruid.Point]vegetation)
+	if g.Branch != NoBranch {
+		g.GenBranchDungeon()
+		return
+	}
 	for {
 		dg := GenRuinsMap
 		switch RandInt(7) {
@@ -426,8 +468,22 @@ const (
 	GenArmour
 	GenWpArm
 	GenExtraCollectables
+	GenBranch
 )
 
+// GenFlavour returns the kind of items generated in the current level. In
+// endless mode, levels past MaxDepth get extra collectables. Branch levels
+// only have a reward at their end.
+func (g *game) GenFlavour() genFlavour {
+	if g.Branch != NoBranch {
+		return GenBranch
+	}
+	if g.Depth > MaxDepth {
+		return GenExtraCollectables
+	}
+	return g.GenPlan[g.Depth]
+}
+
 func (g *game) InitFirstLevel() {
 	g.Depth++ // start at 1
 	g.InitPlayer()
@@ -439,6 +495,7 @@ func (g *game) InitFirstLevel() {
 	g.GeneratedUniques = map[monsterBand]int{}
 	g.Stats.KilledMons = map[monsterKind]int{}
 	g.InitSpecialBands()
+	g.InitBranches()
 	if RandInt(4) > 0 {
 		g.Opts.UnstableLevel = 1 + RandInt(MaxDepth)
 	}
@@ -452,6 +509,9 @@ func (g *game) InitFirstLevel() {
 	if g.Opts.StoneLevel >= 1 && g.Opts.StoneLevel <= 3 {
 		g.Opts.StoneLevel += RandInt(MaxDepth - 2)
 	}
+	if RandInt(4) == 0 {
+		g.Opts.MerchantLevel = 3 + RandInt(MaxDepth-4)
+	}
 	if RandInt(3) == 0 {
 		g.Opts.Alternate = MonsTinyHarpy
 		if RandInt(10) == 0 {
@@ -486,6 +546,7 @@ func (g *game) InitFirstLevel() {
 
 func (g *game) InitLevel() {
 	// Starting data
+	g.DailyReseed(DailyTerrain)
 	if g.Depth == 0 {
 		g.InitFirstLevel()
 	}
@@ -502,22 +563,33 @@ func (g *game) InitLevel() {
 	g.ExclusionsMap = map[gruid.Point]bool{}
 	g.TemporalWalls = map[gruid.Point]bool{}
 	g.DreamingMonster = map[gruid.Point]bool{}
+	g.Traps = map[gruid.Point]trap{}
+	g.KnownTraps = map[gruid.Point]bool{}
+	g.Scent = map[gruid.Point]int{}
+	g.HeardNoise = map[gruid.Point]heardNoise{}
+	g.GenWoodenFloor()
 
 	// Monsters
+	g.DailyReseed(DailyMonsters)
 	g.BandData = MonsBands
-	if bd, ok := g.Opts.SpecialBands[g.Depth]; ok {
+	if g.Branch != NoBranch {
+		g.BandData = BranchData[g.Branch].bands
+	} else if bd, ok := g.Opts.SpecialBands[g.Depth]; ok {
 		g.BandData = bd
+	} else if g.Depth > MaxDepth && RandInt(3) == 0 {
+		g.BandData = MonsSpecialEndBands[RandInt(len(MonsSpecialEndBands))].bands
 	}
 	g.GenMonsters()
 
 	// Collectables
+	g.DailyReseed(DailyItems)
 	g.Collectables = make(map[gruid.Point]collectable)
 	g.GenCollectables()
 
 	// Equipment
 	g.Equipables = make(map[gruid.Point]equipable)
 	g.Rods = map[gruid.Point]rod{}
-	switch g.GenPlan[g.Depth] {
+	switch g.GenFlavour() {
 	case GenWeapon:
 		g.GenWeapon()
 	case GenArmour:
@@ -532,6 +604,10 @@ func (g *game) InitLevel() {
 			g.GenCollectable()
 			g.CollectableScore-- // these are extra
 		}
+	case GenBranch:
+		if g.BranchEnd() {
+			g.GenBranchReward()
+		}
 	}
 	if g.Depth == 1 {
 		// extra collectable
@@ -540,11 +616,8 @@ func (g *game) InitLevel() {
 	}
 
 	// Aptitudes/Mutations
-	if g.Depth == 2 || g.Depth == 5 {
-		apt, ok := g.RandomApt()
-		if ok {
-			g.ApplyAptitude(apt)
-		}
+	if g.Branch == NoBranch && (g.Depth == 2 || g.Depth == 5 || g.Depth == 1 && g.Opts.StartAptitude) {
+		g.GainAptitude()
 	}
 
 	// Stairs
@@ -562,13 +635,19 @@ func (g *game) InitLevel() {
 	} else if g.Depth == WinDepth-1 && nstairs > 2 {
 		nstairs = 2
 	}
+	if g.Branch != NoBranch {
+		nstairs = 0
+		if !g.BranchEnd() {
+			g.Stairs[g.FreeCellForStair(50)] = NormalStair
+		}
+	}
 	for i := 0; i < nstairs; i++ {
 		var p gruid.Point
 		if g.Depth >= WinDepth && g.Depth != MaxDepth-1 {
 			p = g.FreeCellForStair(60)
 			g.Stairs[p] = WinStair
 		}
-		if g.Depth < MaxDepth {
+		if g.Depth < MaxDepth || g.Opts.Endless {
 			if g.Depth > 5 {
 				p = g.FreeCellForStair(50)
 			} else {
@@ -577,6 +656,12 @@ func (g *game) InitLevel() {
 			g.Stairs[p] = NormalStair
 		}
 	}
+	if g.Opts.Revisit && g.Depth > 1 || g.Branch != NoBranch {
+		g.Stairs[g.Player.P] = UpStair
+	}
+	if _, ok := g.BranchEntry(); ok {
+		g.Stairs[g.FreeCellForStair(0)] = BranchStair
+	}
 
 	// Magical Stones
 	g.MagicalStones = map[gruid.Point]stone{}
@@ -590,7 +675,7 @@ func (g *game) InitLevel() {
 		nstones = 3
 	}
 	ustone := stone(0)
-	if g.Depth == g.Opts.StoneLevel {
+	if g.Branch == NoBranch && g.Depth == g.Opts.StoneLevel {
 		ustone = stone(1 + RandInt(NumStones-1))
 		nstones = 10 + RandInt(3)
 		if RandInt(4) == 0 {
@@ -608,6 +693,12 @@ func (g *game) InitLevel() {
 		g.MagicalStones[p] = st
 	}
 
+	// Fountains, altars and shrines
+	g.GenFeatures()
+
+	// Traps
+	g.GenTraps()
+
 	// Simellas
 	g.Simellas = make(map[gruid.Point]int)
 	for i := 0; i < 5; i++ {
@@ -621,16 +712,36 @@ func (g *game) InitLevel() {
 			g.Simellas[p] = 1
 		}
 	}
+	if g.BranchEnd() {
+		// a hoard of simellas
+		p := g.FreeCellForStatic()
+		g.Simellas[p] = 5 + RandInt(5*g.DangerDepth())
+	}
+
+	// Monster behaviours
+	g.GenMonsterBehaviours()
+
+	// Doors, vaults, keys and levers
+	g.GenDoorStates()
+
+	// Lighting
+	g.GenLighting()
 
 	// initialize LOS
 	if g.Depth == 1 {
 		g.Print("You're in Hareka's Underground searching for medicinal simellas. Good luck!")
 		g.PrintStyled("► Type ? for help on keys or use the mouse and [buttons].", logSpecial)
 	}
-	if g.Depth == WinDepth {
+	if g.Branch != NoBranch {
+		g.PrintStyled(g.BranchMessage(), logSpecial)
+	} else if g.Depth == WinDepth {
 		g.PrintStyled("You feel magic in the air. A first way out is close!", logSpecial)
+	} else if g.Depth == MaxDepth && g.Opts.Endless {
+		g.PrintStyled("If rumors are true, you have reached the bottom… but stairs lead even deeper.", logSpecial)
 	} else if g.Depth == MaxDepth {
 		g.PrintStyled("If rumors are true, you have reached the bottom!", logSpecial)
+	} else if g.Depth > MaxDepth {
+		g.PrintfStyled("You are deeper than anyone ever went (depth %d).", logSpecial, g.Depth)
 	}
 	g.ComputeLOS()
 	g.MakeMonstersAware()
@@ -649,6 +760,7 @@ func (g *game) InitLevel() {
 	g.Clouds = map[gruid.Point]cloud{}
 
 	// Events
+	g.DailyReseed(DailyEvents)
 	if g.Depth == 1 {
 		g.Events = &eventQueue{}
 		heap.Init(g.Events)
@@ -659,7 +771,10 @@ func (g *game) InitLevel() {
 	for i := range g.Monsters {
 		g.PushEvent(&monsterEvent{ERank: g.Turn + RandInt(10), EAction: MonsterTurn, NMons: i})
 	}
-	if g.Depth == g.Opts.UnstableLevel {
+	if b, ok := g.BranchEntry(); ok {
+		g.PrintfStyled("You feel a draft coming from a side passage to the %s.", logSpecial, b)
+	}
+	if g.Branch == NoBranch && g.Depth == g.Opts.UnstableLevel {
 		g.PrintStyled("You sense magic instability on this level.", logSpecial)
 		for i := 0; i < 15; i++ {
 			g.PushEvent(&cloudEvent{ERank: g.Turn + 100 + RandInt(900), EAction: ObstructionProgression})
@@ -668,20 +783,26 @@ func (g *game) InitLevel() {
 			g.Opts.UnstableLevel = g.Opts.UnstableLevel + RandInt(MaxDepth-g.Opts.UnstableLevel) + 1
 		}
 	}
+	g.DailyEndGeneration()
 }
 
-func (g *game) CleanEvents() {
+// CleanEvents removes level-specific events from the queue. It returns the
+// removed cloud events.
+func (g *game) CleanEvents() []event {
 	evq := &eventQueue{}
+	cevs := []event{}
 	for g.Events.Len() > 0 {
 		iev := g.PopIEvent()
 		switch iev.Event.(type) {
 		case *monsterEvent:
 		case *cloudEvent:
+			cevs = append(cevs, iev.Event)
 		default:
 			heap.Push(evq, iev)
 		}
 	}
 	g.Events = evq
+	return cevs
 }
 
 func (g *game) StairsSlice() []gruid.Point {
@@ -694,14 +815,27 @@ func (g *game) StairsSlice() []gruid.Point {
 	return stairs
 }
 
+// collectConsumables returns the collectable consumables in a stable order,
+// so that generation only depends on the random seed (see DailyReseed).
+func collectConsumables() []consumable {
+	cs := []consumable{}
+	for c := range ConsumablesCollectData {
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].String() < cs[j].String() })
+	return cs
+}
+
 func (g *game) GenCollectable() {
 	rounds := 100
 	if len(g.LastConsumables) > 3 {
 		g.LastConsumables = g.LastConsumables[1:]
 	}
+	cs := collectConsumables()
 	for {
 	loopcons:
-		for c, data := range ConsumablesCollectData {
+		for _, c := range cs {
+			data := ConsumablesCollectData[c]
 			r := RandInt(data.rarity * rounds)
 			if r != 0 {
 				continue
@@ -774,7 +908,7 @@ func (g *game) GenArmour() {
 }
 
 func (g *game) GenWeapon() {
-	wps := [WeaponNum - 1]weapon{Axe, BattleAxe, Spear, Halberd, AssassinSabre, DancingRapier, HopeSword, Frundis, ElecWhip, HarKarGauntlets, VampDagger, DragonSabre, FinalBlade, DefenderFlail}
+	wps := [WeaponNum - 1]weapon{Axe, BattleAxe, Spear, Halberd, AssassinSabre, DancingRapier, HopeSword, Frundis, ElecWhip, HarKarGauntlets, VampDagger, DragonSabre, FinalBlade, DefenderFlail, ViperDagger, SerratedSabre}
 	onehanded := false
 	for {
 		i := RandInt(len(wps))
@@ -806,23 +940,71 @@ func (g *game) FrundisInLevel() bool {
 
 func (g *game) Descend() bool {
 	g.LevelStats()
-	if strt, ok := g.Stairs[g.Player.P]; ok && strt == WinStair {
+	strt := g.Stairs[g.Player.P]
+	if strt == WinStair {
 		g.StoryPrint("Escaped!")
-		g.ExploredLevels = g.Depth
+		g.ExploredLevels = Max(g.ExploredLevels, g.Depth)
 		g.Depth = -1
 		return true
 	}
-	g.Print("You descend deeper in the dungeon.")
-	g.StoryPrint("Descended deeper in the dungeon.")
-	g.Depth++
+	var id levelID
+	switch {
+	case strt == UpStair && g.Branch != NoBranch:
+		g.Print("You climb the stairs back to the main Underground.")
+		g.StoryPrintf("Left the %s.", g.Branch)
+		id = levelID{Branch: NoBranch, Depth: g.Depth}
+	case strt == UpStair:
+		g.Print("You climb the stairs back up.")
+		g.StoryPrint("Climbed back up in the dungeon.")
+		g.ExploredLevels = Max(g.ExploredLevels, g.Depth-1)
+		id = levelID{Branch: NoBranch, Depth: g.Depth - 1}
+	case strt == BranchStair:
+		b, _ := g.BranchEntry()
+		g.Printf("You take the stairs down to the %s.", b)
+		g.StoryPrintf("Entered the %s.", b)
+		id = levelID{Branch: b, Depth: 1}
+	default:
+		g.Print("You descend deeper in the dungeon.")
+		g.StoryPrint("Descended deeper in the dungeon.")
+		id = g.NextLevelID()
+	}
 	g.DepthPlayerTurn = 0
 	g.Boredom = 0
 	g.PushEvent(&simpleEvent{ERank: g.Ev.Rank(), EAction: PlayerTurn})
-	g.InitLevel()
+	g.ChangeLevel(id)
 	g.Save()
 	return false
 }
 
+// NextLevelID returns the level below the current one.
+func (g *game) NextLevelID() levelID {
+	if g.Branch != NoBranch {
+		return levelID{Branch: g.Branch, Depth: g.BranchDepth + 1}
+	}
+	return levelID{Branch: NoBranch, Depth: g.Depth + 1}
+}
+
+// ChangeLevel moves the player to a new level. The current level is stored
+// in revisit mode, or when going to or from a side branch, and stored levels
+// are restored instead of being generated again.
+func (g *game) ChangeLevel(id levelID) {
+	allies := g.TakeAllies()
+	if g.Opts.Revisit || g.Branch != NoBranch || id.Branch != NoBranch {
+		g.StoreLevel(g.CleanEvents())
+	}
+	if id.Branch == NoBranch {
+		g.Depth = id.Depth
+		g.BranchDepth = 0
+	} else {
+		g.BranchDepth = id.Depth
+	}
+	g.Branch = id.Branch
+	if !g.RestoreLevel() {
+		g.InitLevel()
+	}
+	g.PlaceAllies(allies)
+}
+
 func (g *game) WizardMode() {
 	g.Wizard = true
 	g.Player.Consumables[DescentPotion] = 15
@@ -832,11 +1014,13 @@ func (g *game) WizardMode() {
 func (g *game) ApplyRest() {
 	g.Player.HP = g.Player.HPMax()
 	g.Player.MP = g.Player.MPMax()
+	g.CureStatuses()
 	for _, mons := range g.Monsters {
 		if !mons.Exists() {
 			continue
 		}
 		mons.HP = mons.HPmax
+		mons.CureStatuses()
 	}
 	adjust := 0
 	if g.Player.Armour == HarmonistRobe {
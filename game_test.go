@@ -1,6 +1,10 @@
 package main
 
-import "testing"
+import (
+	"testing"
+
+	"codeberg.org/anaseto/gruid"
+)
 
 func TestInitLevel(t *testing.T) {
 	for i := 0; i < 10; i++ {
@@ -16,3 +20,750 @@ func TestInitLevel(t *testing.T) {
 		}
 	}
 }
+
+func TestDailyInitLevel(t *testing.T) {
+	g1 := &game{Opts: startOpts{Daily: "2026-10-19"}}
+	g2 := &game{Opts: startOpts{Daily: "2026-10-19"}}
+	for depth := 0; depth < 11; depth++ {
+		g1.Depth, g2.Depth = depth, depth
+		g1.InitLevel()
+		g2.InitLevel()
+		if g1.Dungeon.String() != g2.Dungeon.String() {
+			t.Fatalf("Different daily maps at depth %d", g1.Depth)
+		}
+		if len(g1.Monsters) != len(g2.Monsters) {
+			t.Fatalf("Different daily monsters at depth %d", g1.Depth)
+		}
+		for i, mons := range g1.Monsters {
+			if mons.Kind != g2.Monsters[i].Kind || mons.P != g2.Monsters[i].P {
+				t.Errorf("Different daily monster at depth %d: %v vs %v", g1.Depth, mons.Kind, g2.Monsters[i].Kind)
+			}
+		}
+		for p, c := range g1.Collectables {
+			if g2.Collectables[p] != c {
+				t.Errorf("Different daily collectables at depth %d", g1.Depth)
+			}
+		}
+	}
+	for _, g := range []*game{g1, g2} {
+		g.Opts.Branches = map[int]branch{2: BranchWarren}
+		g.ChangeLevel(levelID{Depth: 2})
+	}
+	prev := ""
+	for depth := 1; depth <= BranchWarren.Length(); depth++ {
+		id := levelID{Branch: BranchWarren, Depth: depth}
+		g1.ChangeLevel(id)
+		g2.ChangeLevel(id)
+		if g1.Dungeon.String() != g2.Dungeon.String() {
+			t.Fatalf("Different daily maps at branch depth %d", depth)
+		}
+		if g1.Dungeon.String() == prev {
+			t.Errorf("Same daily map at branch depths %d and %d", depth-1, depth)
+		}
+		prev = g1.Dungeon.String()
+	}
+}
+
+func TestEndlessInitLevel(t *testing.T) {
+	g := &game{Opts: startOpts{Endless: true}}
+	for depth := 0; depth < MaxDepth+10; depth++ {
+		g.Depth = depth
+		g.InitLevel()
+		nstairs := 0
+		for _, st := range g.Stairs {
+			if st == NormalStair {
+				nstairs++
+			}
+		}
+		if nstairs == 0 {
+			t.Errorf("No stairs at depth %d", g.Depth)
+		}
+		for _, m := range g.Monsters {
+			if g.Dungeon.Cell(m.P).T != FreeCell {
+				t.Errorf("Not free: %+v", m.P)
+			}
+		}
+	}
+}
+
+func TestRevisitLevel(t *testing.T) {
+	g := &game{Opts: startOpts{Revisit: true}}
+	g.InitLevel()
+	g.ChangeLevel(levelID{Depth: 2})
+	if st, ok := g.Stairs[g.Player.P]; !ok || st != UpStair {
+		t.Fatalf("No up stairs at arrival: %+v", g.Player.P)
+	}
+	d2 := g.Dungeon.String()
+	g.ChangeLevel(levelID{Depth: 1})
+	if g.Depth != 1 {
+		t.Fatalf("Bad depth: %d", g.Depth)
+	}
+	if _, ok := g.Levels[levelID{Depth: 1}]; ok {
+		t.Errorf("Restored level still stored")
+	}
+	g.ChangeLevel(levelID{Depth: 2})
+	if g.Dungeon.String() != d2 {
+		t.Errorf("Level 2 not restored")
+	}
+	for _, m := range g.Monsters {
+		if m.Exists() && m.P == g.Player.P {
+			t.Errorf("Monster on player: %+v", m.P)
+		}
+	}
+}
+
+func TestBranchLevel(t *testing.T) {
+	g := &game{}
+	g.InitLevel()
+	g.Opts.Branches = map[int]branch{2: BranchWarren}
+	g.ChangeLevel(levelID{Depth: 2})
+	d2 := g.Dungeon.String()
+	for depth := 1; depth <= BranchWarren.Length(); depth++ {
+		g.ChangeLevel(levelID{Branch: BranchWarren, Depth: depth})
+		if g.Depth != 2 || g.BranchDepth != depth {
+			t.Fatalf("Bad branch depth: %d+%d", g.Depth, g.BranchDepth)
+		}
+		if st := g.Stairs[g.Player.P]; st != UpStair {
+			t.Errorf("No up stairs at arrival in branch")
+		}
+	}
+	if len(g.Equipables)+len(g.Rods)+len(g.Collectables) == 0 {
+		t.Errorf("No reward at the end of the branch")
+	}
+	g.ChangeLevel(levelID{Depth: 2})
+	if g.Dungeon.String() != d2 {
+		t.Errorf("Main level not restored after branch")
+	}
+}
+
+func TestAlliesFollow(t *testing.T) {
+	g := &game{}
+	g.InitLevel()
+	var ally *monster
+	for _, m := range g.Monsters {
+		if m.Exists() && m.Kind != MonsMarevorHelith {
+			ally = m
+			break
+		}
+	}
+	if ally == nil {
+		t.Fatal("No monster to charm")
+	}
+	ally.Charm(g)
+	for _, p := range g.Dungeon.FreeNeighbors(g.Player.P) {
+		if !g.MonsterAt(p).Exists() {
+			ally.PlaceAt(g, p)
+			break
+		}
+	}
+	g.ChangeLevel(levelID{Depth: 2})
+	allies := g.Allies()
+	if len(allies) != 1 {
+		t.Fatalf("Bad number of allies: %d", len(allies))
+	}
+	m := allies[0]
+	if g.MonsterAt(m.P) != m || m.P == g.Player.P || Distance(m.P, g.Player.P) > 2*AllyFollowDistance {
+		t.Errorf("Bad ally placement: %+v", m.P)
+	}
+}
+
+func TestRevisitGas(t *testing.T) {
+	g := &game{Opts: startOpts{Revisit: true}}
+	g.InitLevel()
+	p := g.FreeCell()
+	g.ReleaseGas(p, CloudPoison, &simpleEvent{ERank: g.Turn})
+	g.ChangeLevel(levelID{Depth: 2})
+	g.Turn += 5000
+	g.ChangeLevel(levelID{Depth: 1})
+	for q, cld := range g.Clouds {
+		if cld.Gas() {
+			t.Errorf("Gas remains after a long absence at %v", q)
+		}
+	}
+}
+
+func TestGasPoisonKill(t *testing.T) {
+	g := &game{}
+	g.InitLevel()
+	g.Events = &eventQueue{}
+	var m *monster
+	for _, mons := range g.Monsters {
+		if mons.Exists() && mons.Kind.Living() {
+			m = mons
+			break
+		}
+	}
+	if m == nil {
+		t.Fatal("No living monster")
+	}
+	m.HP = 1
+	g.GasAffect(m.P, CloudPoison, &simpleEvent{ERank: g.Turn})
+	for g.Events.Len() > 0 {
+		g.PopIEvent().Event.Action(g)
+	}
+	if m.Exists() {
+		t.Fatalf("Monster survived poison: %d HP", m.HP)
+	}
+	if g.Stats.Killed != 0 || g.Stats.EnvironmentKills != 1 {
+		t.Errorf("Bad kill attribution: %d by player, %d by environment", g.Stats.Killed, g.Stats.EnvironmentKills)
+	}
+}
+
+func TestMonsterTrap(t *testing.T) {
+	DisableAnimations = true
+	g := &game{}
+	g.ui = &gameui{g: g}
+	g.InitLevel()
+	var mons *monster
+	for _, m := range g.Monsters {
+		if m.Exists() && m.Kind != MonsMarevorHelith {
+			mons = m
+			break
+		}
+	}
+	if mons == nil {
+		t.Fatal("No monster")
+	}
+	p := InvalidPos
+	for _, q := range g.Dungeon.FreeNeighbors(mons.P) {
+		if !g.MonsterAt(q).Exists() && q != g.Player.P {
+			p = q
+			break
+		}
+	}
+	if p == InvalidPos {
+		t.Skip("No free cell next to the monster")
+	}
+	g.Traps[p] = TeleportTrap
+	ev := &monsterEvent{ERank: g.Turn, NMons: mons.Index, EAction: MonsterTurn}
+	if mons.MoveTo(g, p, ev) {
+		t.Errorf("Monster stayed on teleport trap: %+v", mons.P)
+	}
+	if !mons.Exists() || mons.P == p || g.MonsterAt(mons.P) != mons {
+		t.Errorf("Bad monster position after teleport: %+v", mons.P)
+	}
+	if _, ok := g.Traps[p]; ok {
+		t.Errorf("Trap not removed")
+	}
+}
+
+func TestAllyTeleport(t *testing.T) {
+	g := &game{}
+	g.InitLevel()
+	var ally, other *monster
+	for _, m := range g.Monsters {
+		if !m.Exists() || m.Kind == MonsMarevorHelith {
+			continue
+		}
+		if ally == nil {
+			ally = m
+		} else if other == nil {
+			other = m
+		}
+	}
+	if ally == nil || other == nil {
+		t.Fatal("Not enough monsters")
+	}
+	ally.Charm(g)
+	if ally.Tactics(g) != 0 {
+		t.Errorf("Ally with band tactics: %v", ally.Tactics(g))
+	}
+	if mates := ally.BandMates(g); mates != nil {
+		t.Errorf("Ally with band mates: %d", len(mates))
+	}
+	targets := map[int]gruid.Point{}
+	for _, m := range g.Monsters {
+		targets[m.Index] = m.Target
+	}
+	ally.GatherBand(g)
+	for _, m := range g.Monsters {
+		if m.Target != targets[m.Index] {
+			t.Errorf("Ally gathered monster %d", m.Index)
+		}
+	}
+	p := g.FreeCell()
+	free := []gruid.Point{}
+	for _, q := range g.Dungeon.FreeNeighbors(p) {
+		if !g.MonsterAt(q).Exists() && q != g.Player.P {
+			free = append(free, q)
+		}
+	}
+	if len(free) < 2 {
+		t.Skip("Not enough free cells around the player")
+	}
+	ally.PlaceAt(g, free[0])
+	ally.Target = ally.P
+	other.PlaceAt(g, free[1])
+	other.State = Wandering
+	other.Target = other.P
+	// teleporting next to monsters makes visible ones aware, without
+	// gathering a band for the ally
+	g.PlacePlayerAt(p)
+	if !g.Player.LOS[ally.P] || !g.Player.LOS[other.P] {
+		t.Fatalf("Monsters not in view: %+v %+v", ally.P, other.P)
+	}
+	if !ally.Ally || ally.State != Hunting || ally.Target != p {
+		t.Errorf("Bad ally state: %v %+v", ally.State, ally.Target)
+	}
+	if other.State != Hunting || other.Target != p {
+		t.Errorf("Adjacent monster not aware: %v %+v", other.State, other.Target)
+	}
+	g.MakeNoise(20, g.Player.P)
+	if !ally.Ally || ally.Band != AllyBand || ally.Target != p {
+		t.Errorf("Ally changed by noise: %v %+v", ally.Band, ally.Target)
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	DisableAnimations = true
+	g := &game{}
+	g.InitLevel()
+	g.ui = &gameui{g: g} // for status end animations
+	g.Events = &eventQueue{}
+	ev := &simpleEvent{ERank: g.Turn}
+	hp := g.Player.HP
+	if !g.PutStatusFor(StatusBerserk, 10, ev) || g.PutStatus(StatusBerserk, ev) {
+		t.Errorf("Berserk not applied exactly once")
+	}
+	if g.Player.Statuses[StatusBerserk] != 1 || g.Player.HP != hp+10 {
+		t.Errorf("Bad berserk: %d instances, %d HP", g.Player.Statuses[StatusBerserk], g.Player.HP)
+	}
+	if !g.PutStatus(StatusSwift, ev) || !g.PutStatus(StatusSwift, ev) || g.Player.Statuses[StatusSwift] != 2 {
+		t.Errorf("Swift did not stack: %d instances", g.Player.Statuses[StatusSwift])
+	}
+	g.PopIEvent().Event.Action(g)
+	if g.Player.HasStatus(StatusBerserk) {
+		t.Errorf("Berserk did not end")
+	}
+	if !g.Player.HasStatus(StatusSlow) || !g.Player.HasStatus(StatusExhausted) || g.Player.HP >= hp+10 {
+		t.Errorf("Bad berserk end effects: %d HP", g.Player.HP)
+	}
+	g.Events = &eventQueue{}
+	hp = g.Player.HP
+	g.PutStatusFor(StatusLignification, 10, ev)
+	if g.Player.HP != hp+10 {
+		t.Errorf("Bad HP when lignified: %d", g.Player.HP)
+	}
+	g.PopIEvent().Event.Action(g)
+	if g.Player.HasStatus(StatusLignification) || g.Player.HP >= hp+10 {
+		t.Errorf("Bad lignification end: %d HP", g.Player.HP)
+	}
+	var mons *monster
+	for _, m := range g.Monsters {
+		if m.Exists() {
+			mons = m
+			break
+		}
+	}
+	mons.PutStatusFor(g, StatusConfusion, 10, ev)
+	if !mons.Status(StatusConfusion) {
+		t.Errorf("Monster not confused")
+	}
+	iev := g.PopIEvent()
+	if mev, ok := iev.Event.(*monsterEvent); !ok || mev.EAction != MonsStatusEnd {
+		t.Fatalf("Bad monster status event: %+v", iev.Event)
+	}
+	iev.Event.Action(g)
+	if mons.Status(StatusConfusion) {
+		t.Errorf("Monster confusion did not end")
+	}
+}
+
+func TestPoisonTicks(t *testing.T) {
+	DisableAnimations = true
+	g := &game{}
+	g.InitLevel()
+	g.ui = &gameui{g: g} // for status end animations
+	g.Events = &eventQueue{}
+	g.Player.HP = 3
+	ev := &simpleEvent{ERank: g.Turn}
+	g.PutStatusFor(StatusPoison, 80, ev)
+	g.PutStatusFor(StatusPoison, 80, ev)
+	for g.Events.Len() > 0 {
+		g.PopIEvent().Event.Action(g)
+	}
+	if g.Player.HP != 1 {
+		t.Errorf("Bad HP after poison: %d", g.Player.HP)
+	}
+	if g.Player.HasStatus(StatusPoison) {
+		t.Errorf("Poison did not end")
+	}
+	g.PutStatus(StatusBleeding, ev)
+	g.CureStatuses()
+	if g.Player.HasStatus(StatusBleeding) {
+		t.Errorf("Bleeding not cured")
+	}
+	// events of cured instances are ignored
+	g.Player.HP = 20
+	g.PutStatusFor(StatusPoison, 80, ev)
+	g.CureStatuses()
+	g.PutStatusFor(StatusPoison, 30, ev)
+	var mons *monster
+	for _, m := range g.Monsters {
+		if m.Exists() {
+			mons = m
+			break
+		}
+	}
+	mons.HP = 20
+	mons.PutStatusFor(g, StatusPoison, 80, ev)
+	mons.CureStatuses()
+	mons.PutStatusFor(g, StatusPoison, 30, ev)
+	for g.Events.Len() > 0 {
+		g.PopIEvent().Event.Action(g)
+	}
+	if g.Player.HP != 17 || mons.HP != 17 {
+		t.Errorf("Bad HP after cured poison: %d, monster %d", g.Player.HP, mons.HP)
+	}
+}
+
+func TestGasDissipates(t *testing.T) {
+	DisableAnimations = true
+	g := &game{}
+	g.InitLevel()
+	g.ui = &gameui{g: g} // the gas may reach the player
+	g.Events = &eventQueue{}
+	p := g.FreeCell()
+	ev := &simpleEvent{ERank: g.Turn}
+	g.ReleaseGas(p, CloudPoison, ev)
+	if g.Clouds[p] != CloudPoison {
+		t.Errorf("No poison gas at %v", p)
+	}
+	for g.Events.Len() > 0 {
+		g.PopIEvent().Event.Action(g)
+	}
+	for q, cld := range g.Clouds {
+		if cld.Gas() {
+			t.Errorf("Gas did not dissipate at %v", q)
+		}
+	}
+}
+
+func TestDarkLOS(t *testing.T) {
+	g := &game{}
+	g.InitLevel()
+	g.Lights = map[gruid.Point]light{}
+	g.ComputeLight()
+	for i := range g.Dungeon.Cells {
+		g.Darkness[idx2Point(i)] = true
+	}
+	g.Clouds = map[gruid.Point]cloud{}
+	g.ComputeLOS()
+	for p := range g.Player.LOS {
+		if Distance(p, g.Player.P) > g.DarkVisionRange() {
+			t.Errorf("Dark position %v in LOS", p)
+		}
+	}
+}
+
+// benchGame returns a depth 11 game with MaxMonsters() monsters, all hunting
+// the player.
+func benchGame() *game {
+	DisableAnimations = true
+	g := &game{}
+	for depth := 0; depth <= 11; depth++ {
+		g.Depth = depth
+		g.InitLevel()
+	}
+	band := len(g.Bands)
+	g.Bands = append(g.Bands, LoneGoblin)
+	for len(g.Monsters) < g.MaxMonsters() {
+		mons := &monster{Kind: MonsGoblin}
+		mons.Init()
+		mons.Index = len(g.Monsters)
+		mons.Band = band
+		mons.PlaceAt(g, g.FreeCellForMonster())
+		g.Monsters = append(g.Monsters, mons)
+	}
+	for _, mons := range g.Monsters {
+		mons.MakeHunt(g)
+	}
+	return g
+}
+
+func BenchmarkMonstersTurn(b *testing.B) {
+	g := benchGame()
+	g.ui = &gameui{g: g}
+	g.Player.HP = 1 << 30
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Stats.Turns++
+		for _, mons := range g.Monsters {
+			if !mons.Exists() {
+				continue
+			}
+			g.Ev = &monsterEvent{ERank: g.Turn, NMons: mons.Index, EAction: MonsterTurn}
+			mons.HandleTurn(g, g.Ev)
+		}
+		g.Events = &eventQueue{}
+	}
+}
+
+func BenchmarkMonstersAPath(b *testing.B) {
+	g := benchGame()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, mons := range g.Monsters {
+			mons.APath(g, mons.P, g.Player.P)
+		}
+	}
+}
+
+// flowGame returns a minimal game whose dungeon is made of two free rows,
+// with a door in the middle of the first one.
+func flowGame() *game {
+	g := &game{Dungeon: &dungeon{Cells: make([]cell, DungeonNCells)}}
+	for x := 1; x < 20; x++ {
+		g.Dungeon.SetCell(gruid.Point{X: x, Y: 1}, FreeCell)
+		g.Dungeon.SetCell(gruid.Point{X: x, Y: 2}, FreeCell)
+	}
+	g.MonstersPosCache = make([]int, DungeonNCells)
+	g.Doors = map[gruid.Point]bool{{X: 10, Y: 1}: true}
+	g.DoorStates = map[gruid.Point]doorState{}
+	g.Clouds = map[gruid.Point]cloud{}
+	return g
+}
+
+func TestFlowPath(t *testing.T) {
+	g := flowGame()
+	from, to := gruid.Point{X: 2, Y: 1}, gruid.Point{X: 18, Y: 1}
+	goblin := &monster{Kind: MonsGoblin}
+	path := goblin.FlowPath(g, from, to)
+	if len(path) != 17 || path[0] != from || path[16] != to {
+		t.Fatalf("Bad flow path: %v", path)
+	}
+	for i := 1; i < len(path); i++ {
+		if Distance(path[i-1], path[i]) != 1 || g.Dungeon.Cell(path[i]).T != FreeCell {
+			t.Errorf("Bad step in flow path: %v", path[i])
+		}
+	}
+	g.Dungeon.SetCell(gruid.Point{X: 10, Y: 2}, WallCell)
+	g.InvalidatePathMaps()
+	hound := &monster{Kind: MonsHound}
+	if path := hound.FlowPath(g, from, to); path != nil {
+		t.Errorf("Flow path through a closed door for a hound: %v", path)
+	}
+	if path := goblin.FlowPath(g, from, to); len(path) != 17 {
+		t.Errorf("No flow path through a closed door for a goblin: %v", path)
+	}
+	g.DoorStates[gruid.Point{X: 10, Y: 1}] = DoorLocked
+	g.InvalidatePathMaps()
+	if path := goblin.FlowPath(g, from, to); path != nil {
+		t.Errorf("Flow path through a locked door: %v", path)
+	}
+	g = flowGame()
+	fire := gruid.Point{X: 5, Y: 1}
+	g.Clouds[fire] = CloudFire
+	to = gruid.Point{X: 8, Y: 1}
+	for _, p := range goblin.FlowPath(g, from, to) {
+		if p == fire {
+			t.Errorf("Flow path through fire")
+		}
+	}
+}
+
+func BenchmarkMonstersFlowPath(b *testing.B) {
+	g := benchGame()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Stats.Turns++
+		for _, mons := range g.Monsters {
+			mons.FlowPath(g, mons.P, g.Player.P)
+		}
+	}
+}
+
+func TestMerchantStock(t *testing.T) {
+	g := &game{}
+	g.InitLevel()
+	g.GenMerchantStock()
+	count := [NumWareKinds]int{}
+	for _, w := range g.MerchantStock {
+		count[w.Kind]++
+		if (w.Kind == WarePotion || w.Kind == WareProjectile) && w.Item == nil {
+			t.Errorf("No item for ware %v", w.Kind)
+		}
+		if g.WarePrice(w) <= 0 {
+			t.Errorf("Bad price for ware %v: %d", w, g.WarePrice(w))
+		}
+	}
+	for i, n := range count {
+		if n != WareData[i].stock {
+			t.Errorf("Bad stock for %v: %d", wareKind(i), n)
+		}
+	}
+	g.Player.Rods = map[rod]rodProps{RodBlink: {RodBlink.MaxCharge() - 1}}
+	if g.RodsCharged() {
+		t.Errorf("Rods charged: %+v", g.Player.Rods)
+	}
+	g.RefillRods()
+	if !g.RodsCharged() {
+		t.Errorf("Rods not charged: %+v", g.Player.Rods)
+	}
+}
+
+func TestGainAptitude(t *testing.T) {
+	g := &game{}
+	g.InitPlayer()
+	g.GainAptitude()
+	g.GainAptitude()
+	if len(g.AptitudeChoices) != 2 {
+		t.Fatalf("Bad number of pending choices: %d", len(g.AptitudeChoices))
+	}
+	apts := g.PendingAptitudes()
+	if len(apts) != 3 {
+		t.Errorf("Bad number of offered aptitudes: %d", len(apts))
+	}
+	g.ApplyChosenAptitude(apts[0])
+	if !g.Player.Aptitudes[apts[0]] || len(g.AptitudeChoices) != 1 {
+		t.Errorf("Chosen aptitude not applied")
+	}
+	for _, apt := range g.PendingAptitudes() {
+		if g.Player.Aptitudes[apt] {
+			t.Errorf("Offered aptitude already gained: %v", apt)
+		}
+	}
+	g.AptitudeChoices = nil
+	g.Opts.RandomAptitudes = true
+	g.GainAptitude()
+	if len(g.AptitudeChoices) > 0 || len(g.Player.Aptitudes) != 2 {
+		t.Errorf("Random aptitude not applied")
+	}
+}
+
+func TestDamageDistribution(t *testing.T) {
+	g := &game{}
+	const n = 100000
+	for _, dt := range []dmgType{DmgPhysical, DmgMagical} {
+		dist := DamageDistribution(dt, 11, 5)
+		count := make([]int, len(dist))
+		for i := 0; i < n; i++ {
+			count[g.DamageRoll(dt, 11, 5).Damage()]++
+		}
+		sum := 0.0
+		for dmg, p := range dist {
+			sum += p
+			if d := p - float64(count[dmg])/n; d > 0.01 || d < -0.01 {
+				t.Errorf("Bad probability for %d dmg: %f (rolled %f)", dmg, p, float64(count[dmg])/n)
+			}
+		}
+		if sum < 0.999 || sum > 1.001 {
+			t.Errorf("Bad distribution sum: %f", sum)
+		}
+	}
+}
+
+func TestVaults(t *testing.T) {
+	locked, barred := 0, 0
+	for i := 0; i < 20 && (locked == 0 || barred == 0); i++ {
+		g := &game{}
+		for depth := 0; depth < 11; depth++ {
+			g.Depth = depth
+			g.InitLevel()
+			// cells reachable by the player without opening vault doors
+			reached := map[gruid.Point]bool{g.Player.P: true}
+			stack := []gruid.Point{g.Player.P}
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				for _, q := range g.Dungeon.FreeNeighbors(p) {
+					if !reached[q] && !g.LockedDoor(q) {
+						reached[q] = true
+						stack = append(stack, q)
+					}
+				}
+			}
+			for p := range g.Vault {
+				if reached[p] {
+					t.Errorf("Vault cell %v reachable at depth %d", p, depth)
+				}
+			}
+			keys := 0
+			for p := range g.Keys {
+				keys++
+				if !reached[p] {
+					t.Errorf("Key %v not reachable at depth %d", p, depth)
+				}
+			}
+			for p, lv := range g.Levers {
+				if !reached[p] {
+					t.Errorf("Lever %v not reachable at depth %d", p, depth)
+				}
+				if g.DoorState(lv.Door) != DoorBarred {
+					t.Errorf("Lever %v does not control a barred door", p)
+				}
+			}
+			for p := range g.Doors {
+				switch g.DoorState(p) {
+				case DoorLocked:
+					locked++
+					keys--
+				case DoorBarred:
+					barred++
+				}
+			}
+			if keys != 0 {
+				t.Errorf("Keys do not match locked doors at depth %d", depth)
+			}
+		}
+		g.Events = &eventQueue{}
+		ev := &simpleEvent{ERank: g.Turn}
+		for p, lv := range g.Levers {
+			g.Player.P = p
+			g.PullLever()
+			if g.DoorState(lv.Door) != DoorOpen {
+				t.Errorf("Lever %v did not open door %v", p, lv.Door)
+			}
+		}
+		for p := range g.Doors {
+			if g.DoorState(p) != DoorLocked {
+				continue
+			}
+			if err := g.OpenLockedDoor(p, ev); err == nil {
+				t.Errorf("Door %v opened without a key", p)
+			}
+			g.Player.Keys = 1
+			if err := g.OpenLockedDoor(p, ev); err != nil || g.DoorState(p) != DoorOpen || g.Player.Keys != 0 {
+				t.Errorf("Door %v not unlocked with a key", p)
+			}
+		}
+	}
+	if locked == 0 || barred == 0 {
+		t.Errorf("No vaults generated: %d locked, %d barred", locked, barred)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	g := &game{}
+	for depth := 0; depth < 11; depth++ {
+		g.Depth = depth
+		g.InitLevel()
+		for p := range g.Features {
+			if g.Dungeon.Cell(p).T != FreeCell {
+				t.Errorf("Feature not on a free cell: %+v", p)
+			}
+			if _, ok := g.Stairs[p]; ok {
+				t.Errorf("Feature on stairs: %+v", p)
+			}
+		}
+	}
+	g.Events = &eventQueue{}
+	ev := &simpleEvent{ERank: g.Turn}
+	p := g.FreeCellForStatic()
+	g.Features = map[gruid.Point]feature{p: Fountain}
+	g.Player.P = p
+	if err := g.UseFeature(ev); err != nil {
+		t.Fatalf("Could not drink from fountain: %v", err)
+	}
+	if g.Features[p] != DryFountain {
+		t.Errorf("Fountain did not dry up: %v", g.Features[p])
+	}
+	if err := g.UseFeature(ev); err == nil {
+		t.Errorf("Drank twice from fountain")
+	}
+	g.Features[p] = Altar
+	g.Player.Simellas = g.AltarCost() - 1
+	if err := g.UseFeature(ev); err == nil || g.Features[p] != Altar {
+		t.Errorf("Offering made without enough simellas")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import "codeberg.org/anaseto/gruid"
+
+func (cld cloud) String() (text string) {
+	switch cld {
+	case CloudFog:
+		text = "a dense fog"
+	case CloudFire:
+		text = "burning flames"
+	case CloudNight:
+		text = "night clouds"
+	case CloudPoison:
+		text = "poison gas"
+	case CloudConfusion:
+		text = "confusing gas"
+	case CloudSteam:
+		text = "scalding steam"
+	}
+	return text
+}
+
+func (cld cloud) Description() (text string) {
+	switch cld {
+	case CloudFog:
+		text = "A dense fog blocks your line of sight. It dissipates after some time."
+	case CloudFire:
+		text = "Burning flames block your line of sight and burn any creature standing in them. They spread to nearby foliage and doors."
+	case CloudNight:
+		text = "Night clouds block your line of sight and make any creature standing in them fall asleep."
+	case CloudPoison:
+		text = "Poison gas poisons any living creature standing in it."
+	case CloudConfusion:
+		text = "Confusing gas confuses any creature standing in it."
+	case CloudSteam:
+		text = "Scalding steam blocks your line of sight and burns any creature standing in it."
+	}
+	if cld.Gas() {
+		text += " Gas drifts and spreads through open ground, but not through closed doors, and dissipates over time."
+	}
+	return text
+}
+
+// Gas reports whether the cloud is a gas that drifts and spreads.
+func (cld cloud) Gas() bool {
+	switch cld {
+	case CloudPoison, CloudConfusion, CloudSteam:
+		return true
+	}
+	return false
+}
+
+// Opaque reports whether the cloud blocks line of sight.
+func (cld cloud) Opaque() bool {
+	switch cld {
+	case CloudPoison, CloudConfusion:
+		return false
+	}
+	return true
+}
+
+// GasDensity is the density of freshly released gas. Gas spreads to
+// neighbor cells with a lower density, so that it eventually dissipates.
+const GasDensity = 4
+
+// ReleaseGas releases some gas at a given position and its neighbors.
+func (g *game) ReleaseGas(p gruid.Point, cld cloud, ev event) {
+	g.Gas(p, cld, GasDensity, ev)
+	for _, q := range g.GasNeighbors(p) {
+		g.Gas(q, cld, GasDensity-1, ev)
+	}
+	g.GasAffect(p, cld, ev)
+	if cld.Opaque() {
+		g.ComputeLOS()
+	}
+}
+
+// Gas puts gas with a given density at a position without clouds.
+func (g *game) Gas(p gruid.Point, cld cloud, density int, ev event) {
+	if _, ok := g.Clouds[p]; ok {
+		return
+	}
+	g.Clouds[p] = cld
+	g.PushEvent(&cloudEvent{ERank: ev.Rank() + 10, EAction: GasProgression, P: p, Density: density})
+}
+
+// GasNeighbors returns the neighbor positions to which gas can spread.
+func (g *game) GasNeighbors(p gruid.Point) []gruid.Point {
+	ps := []gruid.Point{}
+	for _, q := range g.Dungeon.FreeNeighbors(p) {
+		if g.ClosedDoor(q) {
+			continue
+		}
+		if _, ok := g.Clouds[q]; ok {
+			continue
+		}
+		ps = append(ps, q)
+	}
+	return ps
+}
+
+// GasProgression makes the gas affect any creature standing in it, and then
+// dissipate, spread or drift.
+func (cev *cloudEvent) GasProgression(g *game) {
+	cld, ok := g.Clouds[cev.P]
+	if !ok || !cld.Gas() {
+		return
+	}
+	g.GasAffect(cev.P, cld, cev)
+	if RandInt(3) == 0 {
+		cev.Density--
+	}
+	if cev.Density <= 0 {
+		delete(g.Clouds, cev.P)
+		if cld.Opaque() {
+			g.ComputeLOS()
+		}
+		return
+	}
+	ps := g.GasNeighbors(cev.P)
+	if len(ps) > 0 {
+		q := ps[RandInt(len(ps))]
+		switch {
+		case cev.Density > 1 && RandInt(2) == 0:
+			g.Gas(q, cld, cev.Density-1, cev)
+		case RandInt(4) == 0:
+			delete(g.Clouds, cev.P)
+			g.Clouds[q] = cld
+			cev.P = q
+		}
+		if cld.Opaque() {
+			g.ComputeLOS()
+		}
+	}
+	cev.Renew(g, 10)
+}
+
+// GasAffect applies the effects of the gas to any creature at a given
+// position.
+func (g *game) GasAffect(p gruid.Point, cld cloud, ev event) {
+	if p == g.Player.P {
+		switch cld {
+		case CloudPoison:
+			if !g.Player.HasStatus(StatusPoison) {
+				g.Poison(ev)
+			}
+		case CloudConfusion:
+			if !g.Player.Aptitudes[AptConfusingGas] {
+				g.Confusion(ev)
+			}
+		case CloudSteam:
+			if g.Player.HP > 1 {
+				damage := Min(1+RandInt(2), g.Player.HP-1)
+				g.Player.HP -= damage
+				g.PrintfStyled("The steam scalds you (%d dmg).", logMonsterHit, damage)
+			}
+		}
+		g.StopAuto()
+		return
+	}
+	mons := g.MonsterAt(p)
+	if !mons.Exists() {
+		return
+	}
+	switch cld {
+	case CloudPoison:
+		if !mons.Kind.Living() || mons.Status(StatusPoison) {
+			break
+		}
+		mons.PutStatusFrom(g, StatusPoison, StatusPoison.Duration(), KillByEnvironment, ev)
+		if g.Player.LOS[mons.P] {
+			g.Printf("%s is poisoned by the gas.", mons.Kind.Definite(true))
+		}
+	case CloudConfusion:
+		if mons.Status(StatusConfusion) {
+			break
+		}
+		mons.EnterConfusion(g, ev)
+		if g.Player.LOS[mons.P] {
+			g.Printf("%s appears confused.", mons.Kind.Definite(true))
+		}
+	case CloudSteam:
+		mons.HP -= 1 + RandInt(2)
+		if mons.HP <= 0 {
+			if g.Player.LOS[mons.P] {
+				g.PrintfStyled("%s is killed by the steam.", logPlayerHit, mons.Kind.Definite(true))
+			}
+			g.HandleKill(mons, KillByEnvironment, ev)
+		} else {
+			mons.MakeAwareIfHurt(g)
+		}
+	}
+}
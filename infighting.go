@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+
+	"codeberg.org/anaseto/gruid"
+)
+
+// killSource identifies what killed a monster, for kill attribution.
+type killSource int
+
+const (
+	KillByPlayer killSource = iota
+	KillByAlly
+	KillByMonster
+	KillByEnvironment
+)
+
+// KillSource returns the kill source for damage inflicted by the monster.
+func (m *monster) KillSource() killSource {
+	if m.Ally {
+		return KillByAlly
+	}
+	return KillByMonster
+}
+
+// DamageMonster inflicts some damage to a monster, handling its death. It
+// returns true if the monster was killed.
+func (g *game) DamageMonster(mons *monster, damage int, src killSource, ev event) bool {
+	mons.HP -= damage
+	if mons.HP > 0 {
+		return false
+	}
+	g.HandleKill(mons, src, ev)
+	return true
+}
+
+// HitMonster makes the monster attack another monster in melee. It follows
+// HitPlayer, without the effects specific to the player.
+func (m *monster) HitMonster(g *game, target *monster, ev event) {
+	if !target.Exists() || Distance(m.P, target.P) > 1 {
+		return
+	}
+	evasion := target.EvasionRoll(RandInt(target.Evasion))
+	acc := RandInt(m.Accuracy)
+	dr := g.DamageRoll(DmgPhysical, m.Attack, target.DefenseArmor())
+	m.CombatLogMonsterAttack(g, target, "attacks", acc, evasion, dr)
+	target.ReactToAttack(g, m)
+	if acc <= evasion {
+		if m.SeenFighting(g, target) {
+			g.Printf("%s misses %s.", m.Kind.Definite(true), target.Kind.Definite(false))
+		}
+		return
+	}
+	noise := BaseHitNoise
+	if dr.Clang {
+		noise += target.DefenseArmor()
+	}
+	g.MakeNoise(noise, target.P)
+	attack := dr.Damage()
+	if m.SeenFighting(g, target) {
+		verb := "hits"
+		if attack >= target.HP {
+			verb = "kills"
+		}
+		g.PrintfStyled("%s %s %s (%d dmg).", m.FightStyle(target), m.Kind.Definite(true), verb, target.Kind.Definite(false), attack)
+	}
+	g.DamageMonster(target, attack, m.KillSource(), ev)
+}
+
+// ThrowAtMonster makes the monster's projectile hit another monster standing
+// in the way to the player.
+func (m *monster) ThrowAtMonster(g *game, target *monster, what string, dmg int, ev event) bool {
+	evasion := target.EvasionRoll(RandInt(target.Evasion))
+	acc := RandInt(m.Accuracy)
+	dr := g.DamageRoll(DmgPhysical, dmg, target.DefenseArmor())
+	m.CombatLogMonsterAttack(g, target, "throws "+what+" at", acc, evasion, dr)
+	target.ReactToAttack(g, m)
+	if acc <= evasion {
+		if m.SeenFighting(g, target) {
+			g.Printf("%s throws %s at %s, but misses.", m.Kind.Definite(true), what, target.Kind.Definite(false))
+		}
+	} else {
+		g.MakeNoise(BaseHitNoise, target.P)
+		attack := dr.Damage()
+		if m.SeenFighting(g, target) {
+			g.PrintfStyled("%s throws %s at %s (%d dmg).", m.FightStyle(target), m.Kind.Definite(true), what, target.Kind.Definite(false), attack)
+		}
+		g.DamageMonster(target, attack, m.KillSource(), ev)
+	}
+	m.ExhaustTime(g, 50+RandInt(50))
+	ev.Renew(g, m.AttackDelay())
+	return true
+}
+
+// SeenFighting reports whether the player sees the monster fighting target.
+func (m *monster) SeenFighting(g *game, target *monster) bool {
+	return g.Player.LOS[m.P] || g.Player.LOS[target.P]
+}
+
+// FightStyle returns the log style for the monster attacking target.
+func (m *monster) FightStyle(target *monster) logStyle {
+	switch {
+	case m.Ally:
+		return logPlayerHit
+	case target.Ally:
+		return logMonsterHit
+	default:
+		return logNormal
+	}
+}
+
+// CombatLogMonsterAttack records a monster's attack against another monster
+// in the verbose combat log.
+func (m *monster) CombatLogMonsterAttack(g *game, target *monster, what string, acc, evasion int, dr damageRoll) {
+	if !GameConfig.VerboseCombatLog {
+		return
+	}
+	s := fmt.Sprintf("%s %s %s: accuracy %d (max %d) vs evasion %d (max %d): ",
+		m.Kind.Definite(true), what, target.Kind.Definite(false), acc, m.Accuracy, evasion, target.Evasion)
+	if acc > evasion {
+		s += fmt.Sprintf("hit, %s = %d dmg", dr, dr.Damage())
+	} else {
+		s += "miss"
+	}
+	g.CombatPrintf("%s", s)
+}
+
+// ReactToAttack makes a hostile monster attacked by another monster turn
+// against it.
+func (m *monster) ReactToAttack(g *game, attacker *monster) {
+	if m.Ally || !attacker.Exists() {
+		return
+	}
+	if m.State == Resting && g.Player.LOS[m.P] {
+		g.Printf("%s awakens.", m.Kind.Definite(true))
+	}
+	m.State = Hunting
+	m.Target = attacker.P
+	m.Path = nil
+}
+
+// NeighborMonster returns a random monster adjacent to the monster, or nil.
+// Only cardinal neighbors are considered for confused monsters.
+func (m *monster) NeighborMonster(g *game) *monster {
+	var neighbors []gruid.Point
+	if m.Status(StatusConfusion) {
+		neighbors = g.Dungeon.CardinalFreeNeighbors(m.P)
+	} else {
+		neighbors = g.Dungeon.FreeNeighbors(m.P)
+	}
+	monsters := []*monster{}
+	for _, p := range neighbors {
+		if mons := g.MonsterAt(p); mons.Exists() {
+			monsters = append(monsters, mons)
+		}
+	}
+	if len(monsters) == 0 {
+		return nil
+	}
+	return monsters[RandInt(len(monsters))]
+}
+
+// NearestMonster returns the nearest other monster in sight range of the
+// monster, or nil.
+func (m *monster) NearestMonster(g *game) *monster {
+	const maxDistance = 6
+	var nearest *monster
+	best := maxDistance + 1
+	for _, mons := range g.Monsters {
+		if !mons.Exists() || mons == m {
+			continue
+		}
+		if d := Distance(m.P, mons.P); d < best {
+			nearest = mons
+			best = d
+		}
+	}
+	return nearest
+}
+
+// Infight makes a confused monster occasionally lash out at neighbouring
+// monsters, and an enraged monster go after the nearest creature, be it a
+// monster or the player. It returns true if the monster attacked.
+func (m *monster) Infight(g *game, ev event) bool {
+	adelay := m.AttackDelay()
+	if m.Status(StatusConfusion) && RandInt(3) == 0 {
+		if mons := m.NeighborMonster(g); mons != nil {
+			if m.SeenFighting(g, mons) {
+				g.Printf("%s lashes out in confusion.", m.Kind.Definite(true))
+			}
+			m.HitMonster(g, mons, ev)
+			ev.Renew(g, adelay)
+			return true
+		}
+	}
+	if !m.Status(StatusEnraged) {
+		return false
+	}
+	mons := m.NearestMonster(g)
+	if mons == nil || Distance(m.P, g.Player.P) < Distance(m.P, mons.P) {
+		if m.Ally && Distance(m.P, g.Player.P) == 1 {
+			m.HitPlayer(g, ev)
+			ev.Renew(g, adelay)
+			return true
+		}
+		return false
+	}
+	if Distance(m.P, mons.P) == 1 {
+		m.HitMonster(g, mons, ev)
+		ev.Renew(g, adelay)
+		return true
+	}
+	m.State = Hunting
+	m.Target = mons.P
+	return false
+}
+
+// EnterEnrage makes the monster enraged for some time: it attacks whatever
+// creature is nearest.
+func (m *monster) EnterEnrage(g *game, ev event) {
+	m.PutStatus(g, StatusEnraged, ev)
+}
+
+// RangeTarget returns the first monster standing in the way between the
+// monster and the player, or nil.
+func (m *monster) RangeTarget(g *game) *monster {
+	ray := g.Ray(m.P)
+	if len(ray) == 0 {
+		return nil
+	}
+	for _, p := range ray[1:] {
+		if mons := g.MonsterAt(p); mons.Exists() {
+			return mons
+		}
+	}
+	return nil
+}
+
+// ProjectileBlocked reports whether the monster would rather not throw a
+// projectile at the player, because of another hostile monster in the way.
+// Allies in the way are hit instead, and enraged monsters do not care.
+func (m *monster) ProjectileBlocked(g *game) bool {
+	mons := m.RangeTarget(g)
+	return mons != nil && !mons.Ally && !m.Status(StatusEnraged)
+}
+
+// Projectile reports whether the monster's ranged attack is a projectile
+// that can hit creatures in the way.
+func (mk monsterKind) Projectile() bool {
+	switch mk {
+	case MonsCyclop, MonsGoblinWarrior, MonsSatowalgaPlant:
+		return true
+	default:
+		return false
+	}
+}
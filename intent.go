@@ -0,0 +1,223 @@
+package main
+
+import "fmt"
+
+// monsterIntent is what a visible monster is expected to do on its next
+// turn, as shown in the examine view.
+type monsterIntent int
+
+const (
+	IntentNone monsterIntent = iota
+	IntentCloseIn
+	IntentMelee
+	IntentBlink
+	IntentPrepareRanged
+	IntentRanged
+	IntentFlee
+	IntentConfused
+	IntentKeepDistance
+	IntentAttackAlly
+	IntentInfight
+)
+
+func (i monsterIntent) String() (text string) {
+	switch i {
+	case IntentCloseIn:
+		text = "closing in"
+	case IntentMelee:
+		text = "melee"
+	case IntentBlink:
+		text = "melee, blinks if hurt"
+	case IntentPrepareRanged:
+		text = "aiming"
+	case IntentRanged:
+		text = "ranged attack"
+	case IntentFlee:
+		text = "fleeing"
+	case IntentConfused:
+		text = "erratic"
+	case IntentKeepDistance:
+		text = "stepping back"
+	case IntentAttackAlly:
+		text = "attacking your ally"
+	case IntentInfight:
+		text = "infighting"
+	}
+	return text
+}
+
+// Intent guesses what the monster will do on its next turn. It follows the
+// order of the checks in HandleTurn, but does not predict random choices,
+// like waiting in ambush next to a door.
+func (m *monster) Intent(g *game) monsterIntent {
+	switch m.State {
+	case Resting:
+		return IntentNone
+	case Fleeing:
+		return IntentFlee
+	case Wandering:
+		if m.Kind == MonsSatowalgaPlant {
+			return IntentNone
+		}
+		return IntentCloseIn
+	}
+	if !m.Status(StatusEnraged) && m.Frightened(g) {
+		return IntentFlee
+	}
+	if m.Status(StatusEnraged) {
+		if mons := m.NearestMonster(g); mons != nil && Distance(m.P, g.Player.P) >= Distance(m.P, mons.P) {
+			return IntentInfight
+		}
+	}
+	if m.Tactics(g)&TacticRangedBehind != 0 && m.Kind.Ranged() {
+		if _, ok := m.KeepDistanceCell(g); ok {
+			return IntentKeepDistance
+		}
+	}
+	d := Distance(m.P, g.Player.P)
+	if (m.Kind.Ranged() || m.Kind.Smiting()) && g.Player.LOS[m.P] && (d > 1 || m.Kind == MonsSatowalgaPlant) &&
+		!m.Status(StatusExhausted) && !m.IntentRangeBlocked(g) {
+		if !m.FireReady {
+			if d <= 3 {
+				return IntentPrepareRanged
+			}
+		} else {
+			return IntentRanged
+		}
+	}
+	if m.Kind == MonsSatowalgaPlant || m.Status(StatusLignification) && d > 1 {
+		return IntentNone
+	}
+	if d > 1 && m.AdjacentAlly(g) != nil {
+		return IntentAttackAlly
+	}
+	if m.Status(StatusConfusion) {
+		// confused monsters only attack in cardinal directions
+		if d == 1 {
+			switch Dir(m.P, g.Player.P) {
+			case E, N, W, S:
+				return IntentMelee
+			}
+		}
+		return IntentConfused
+	}
+	if d == 1 {
+		if m.Kind == MonsTinyHarpy {
+			return IntentBlink
+		}
+		return IntentMelee
+	}
+	return IntentCloseIn
+}
+
+// RangedAction returns a description of the monster's ranged or smiting
+// attack.
+func (mk monsterKind) RangedAction() (text string) {
+	switch mk {
+	case MonsLich:
+		text = "throw a bolt of torment"
+	case MonsCyclop:
+		text = "throw a rock"
+	case MonsGoblinWarrior:
+		text = "throw a javelin"
+	case MonsSatowalgaPlant:
+		text = "throw acid"
+	case MonsMadNixe:
+		text = "lure you to her"
+	case MonsVampire:
+		text = "spit at you"
+	case MonsTreeMushroom:
+		text = "throw lignifying spores"
+	case MonsMirrorSpecter:
+		text = "absorb your mana"
+	case MonsMindCelmist:
+		text = "use a mind attack"
+	}
+	return text
+}
+
+// IntentDescription returns sentences describing the monster's intent and
+// speed compared to the player.
+func (m *monster) IntentDescription(g *game) string {
+	var s string
+	switch m.Intent(g) {
+	case IntentNone:
+		if m.State == Resting {
+			s = "It is resting and will not act unless it notices you."
+		} else if m.Status(StatusLignification) {
+			s = "It is lignified and cannot move."
+		} else {
+			s = "It will stay where it is."
+		}
+	case IntentCloseIn:
+		if m.State == Wandering {
+			s = "It has not noticed you yet."
+		} else {
+			s = "It will come closer."
+		}
+	case IntentMelee:
+		s = "It will attack you in melee."
+	case IntentBlink:
+		s = "It will attack you in melee, and blink away if hurt."
+	case IntentPrepareRanged:
+		s = fmt.Sprintf("It is getting ready to %s.", m.Kind.RangedAction())
+	case IntentRanged:
+		s = fmt.Sprintf("It will %s.", m.Kind.RangedAction())
+	case IntentFlee:
+		s = "It will try to run away from you."
+	case IntentConfused:
+		s = "It is confused and will move erratically. It may lash out at adjacent monsters."
+	case IntentKeepDistance:
+		s = "It will step back behind its band mates."
+	case IntentAttackAlly:
+		s = "It will attack one of your allies."
+	case IntentInfight:
+		s = "It is enraged and will go after the nearest monster."
+	}
+	switch {
+	case m.Kind.Projectile() && m.Kind != MonsSatowalgaPlant:
+		s += fmt.Sprintf(" It can %s from any distance in its line of sight, but not when adjacent. Any of your allies standing in the way is hit instead.", m.Kind.RangedAction())
+	case m.Kind.Projectile():
+		s += fmt.Sprintf(" It can %s from any distance in its line of sight. Any of your allies standing in the way is hit instead.", m.Kind.RangedAction())
+	case m.Kind.Ranged():
+		s += fmt.Sprintf(" It can %s from any distance in its line of sight, but not when adjacent, nor if a creature stands in the way.", m.Kind.RangedAction())
+	case m.Kind.Smiting():
+		s += fmt.Sprintf(" It can %s from any distance in its line of sight.", m.Kind.RangedAction())
+	}
+	movedelay := m.MovementDelay()
+	attackdelay := m.AttackDelay()
+	pmove := g.ActionDelay(10 + g.MovementDelayModifier())
+	pattack := g.ActionDelay(10)
+	if m.Kind != MonsSatowalgaPlant {
+		s += fmt.Sprintf(" It moves %s (%d vs %d) and", CompareSpeed(movedelay, pmove), movedelay, pmove)
+	} else {
+		s += " It"
+	}
+	s += fmt.Sprintf(" attacks %s (%d vs %d).", CompareSpeed(attackdelay, pattack), attackdelay, pattack)
+	return s
+}
+
+// CompareSpeed describes a monster delay compared to a player delay.
+func CompareSpeed(mdelay, pdelay int) string {
+	switch {
+	case mdelay < pdelay:
+		return "faster than you"
+	case mdelay > pdelay:
+		return "slower than you"
+	default:
+		return "as fast as you"
+	}
+}
+
+// IntentRangeBlocked reports whether the monster's ranged attack on the player
+// is prevented by some creature in the way.
+func (m *monster) IntentRangeBlocked(g *game) bool {
+	switch {
+	case m.Kind.Smiting():
+		return false
+	case m.Kind.Projectile():
+		return m.ProjectileBlocked(g)
+	default:
+		return m.RangeBlocked(g)
+	}
+}
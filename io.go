@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func Replay(file string) error {
@@ -57,7 +58,7 @@ func (g *game) Save() error {
 		g.Print(err.Error())
 		return err
 	}
-	saveFile := filepath.Join(dataDir, "save")
+	saveFile := filepath.Join(dataDir, g.SaveFile())
 	data, err := g.GameSave()
 	if err != nil {
 		g.Print(err.Error())
@@ -71,8 +72,15 @@ func (g *game) Save() error {
 	return nil
 }
 
+func (g *game) SaveFile() string {
+	if g.Opts.Daily != "" {
+		return "daily-save"
+	}
+	return "save"
+}
+
 func (g *game) RemoveSaveFile() error {
-	return g.RemoveDataFile("save")
+	return g.RemoveDataFile(g.SaveFile())
 }
 
 func (g *game) Load() (bool, error) {
@@ -80,7 +88,7 @@ func (g *game) Load() (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	saveFile := filepath.Join(dataDir, "save")
+	saveFile := filepath.Join(dataDir, g.SaveFile())
 	_, err = os.Stat(saveFile)
 	if err != nil {
 		// no save file, new game
@@ -221,3 +229,99 @@ func (g *game) WriteDump() error {
 	}
 	return nil
 }
+
+// WriteCombatLog exports the verbose combat log to a file in the data
+// directory.
+func (g *game) WriteCombatLog() error {
+	dataDir, err := g.DataDir()
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(dataDir, "combatlog"), []byte(g.CombatLogText()), 0644)
+	if err != nil {
+		return fmt.Errorf("writing combat log: %v", err)
+	}
+	return nil
+}
+
+// CheckDaily returns an error if the current daily challenge was already
+// started and cannot be continued from a save.
+func (g *game) CheckDaily() error {
+	dataDir, err := g.DataDir()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stat(filepath.Join(dataDir, g.SaveFile()))
+	if err == nil {
+		// continue the daily run
+		return nil
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dataDir, "daily-played"))
+	if err != nil {
+		// no daily played yet
+		return nil
+	}
+	for _, date := range strings.Split(string(data), "\n") {
+		if date == g.Opts.Daily {
+			return fmt.Errorf("daily challenge %s was already played", g.Opts.Daily)
+		}
+	}
+	return nil
+}
+
+// RegisterDaily records that the current daily challenge was started, so that
+// it cannot be restarted from scratch.
+func (g *game) RegisterDaily() error {
+	return g.AppendDataFile("daily-played", g.Opts.Daily+"\n")
+}
+
+func (g *game) RecordDailyScore() error {
+	name := os.Getenv("USER")
+	if name == "" {
+		name = "anonymous"
+	}
+	return g.AppendDataFile("daily-scores", g.NewDailyScore(name).String()+"\n")
+}
+
+func (g *game) DailyScores() ([]dailyScore, error) {
+	dataDir, err := g.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dataDir, "daily-scores"))
+	if err != nil {
+		return nil, err
+	}
+	return parseDailyScores(data, g.Opts.Daily)
+}
+
+func (g *game) AppendDataFile(file, s string) error {
+	dataDir, err := g.DataDir()
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dataDir, file), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(s)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// NewGame starts a new game, registering it first if it is a daily run.
+func (g *game) NewGame() {
+	if g.Opts.Daily != "" {
+		err := g.RegisterDaily()
+		if err != nil {
+			g.PrintfStyled("Error registering daily challenge: %v", logError, err)
+		}
+	}
+	g.InitLevel()
+	if g.Opts.Daily != "" {
+		g.PrintfStyled("This is the daily challenge of %s.", logSpecial, g.Opts.Daily)
+	}
+}
@@ -123,7 +123,7 @@ func (p potion) Desc() (text string) {
 	case SwapPotion:
 		text = "makes you swap positions with monsters instead of attacking. Ranged monsters can still damage you."
 	case ShadowsPotion:
-		text = "reduces your line of sight range to 1. Because monsters only can see you if you see them, this makes it easier to get out of sight of monsters so that they eventually stop chasing you."
+		text = "reduces your line of sight range to 1. Because monsters only can see you if you see them, this makes it easier to get out of sight of monsters so that they eventually stop chasing you. In darkness, even adjacent monsters may fail to notice you."
 	case TormentPotion:
 		text = "halves HP of every creature in sight, including the player, and destroys visible walls. Extremely noisy. It can burn foliage and doors."
 	case AccuracyPotion:
@@ -204,8 +204,7 @@ func (g *game) QuaffTeleportation(ev event) error {
 		return errors.New("You already quaffed a potion of teleportation.")
 	}
 	delay := 20 + RandInt(30)
-	g.Player.Statuses[StatusTele] = 1
-	g.PushEvent(&simpleEvent{ERank: ev.Rank() + delay, EAction: Teleportation})
+	g.PutStatusFor(StatusTele, delay, ev)
 	g.Printf("You quaff the %s. You feel unstable.", TeleportationPotion)
 	return nil
 }
@@ -217,12 +216,8 @@ func (g *game) QuaffBerserk(ev event) error {
 	if g.Player.HasStatus(StatusBerserk) {
 		return errors.New("You are already berserk.")
 	}
-	g.Player.Statuses[StatusBerserk] = 1
-	end := ev.Rank() + 65 + RandInt(20)
-	g.PushEvent(&simpleEvent{ERank: end, EAction: BerserkEnd})
-	g.Player.Expire[StatusBerserk] = end
+	g.PutStatus(StatusBerserk, ev)
 	g.Printf("You quaff the %s. You feel a sudden urge to kill things.", BerserkPotion)
-	g.Player.HP += 10
 	return nil
 }
 
@@ -233,6 +228,7 @@ func (g *game) QuaffHealWounds(ev event) error {
 		g.Player.HP = g.Player.HPMax()
 	}
 	g.Printf("You quaff the %s (%d -> %d).", HealWoundsPotion, hp, g.Player.HP)
+	g.CureStatuses()
 	return nil
 }
 
@@ -251,36 +247,28 @@ func (g *game) QuaffDescent(ev event) error {
 	//if g.Player.HasStatus(StatusLignification) {
 	//return errors.New("You cannot descend while lignified.")
 	//}
-	if g.Depth >= MaxDepth {
+	if g.Depth >= MaxDepth && !g.Opts.Endless || g.BranchEnd() {
 		return errors.New("You cannot descend any deeper!")
 	}
 	g.Printf("You quaff the %s. You fall through the ground.", DescentPotion)
 	g.LevelStats()
 	g.StoryPrint("Descended deeper into the dungeon.")
-	g.Depth++
 	g.DepthPlayerTurn = 0
-	g.InitLevel()
+	g.ChangeLevel(g.NextLevelID())
 	g.Save()
 	return nil
 }
 
 func (g *game) QuaffSwiftness(ev event) error {
-	g.Player.Statuses[StatusSwift]++
-	end := ev.Rank() + 85 + RandInt(20)
-	g.PushEvent(&simpleEvent{ERank: end, EAction: HasteEnd})
-	g.Player.Expire[StatusSwift] = end
-	g.Player.Statuses[StatusAgile]++
-	g.PushEvent(&simpleEvent{ERank: end, EAction: EvasionEnd})
-	g.Player.Expire[StatusAgile] = end
+	duration := StatusSwift.Duration()
+	g.PutStatusFor(StatusSwift, duration, ev)
+	g.PutStatusFor(StatusAgile, duration, ev)
 	g.Printf("You quaff the %s. You feel speedy and agile.", SwiftnessPotion)
 	return nil
 }
 
 func (g *game) QuaffDigPotion(ev event) error {
-	g.Player.Statuses[StatusDig] = 1
-	end := ev.Rank() + 75 + RandInt(20)
-	g.PushEvent(&simpleEvent{ERank: end, EAction: DigEnd})
-	g.Player.Expire[StatusDig] = end
+	g.PutStatus(StatusDig, ev)
 	g.Printf("You quaff the %s. You feel like an earth dragon.", DigPotion)
 	return nil
 }
@@ -289,10 +277,7 @@ func (g *game) QuaffSwapPotion(ev event) error {
 	if g.Player.HasStatus(StatusLignification) {
 		return errors.New("You cannot drink this potion while lignified.")
 	}
-	g.Player.Statuses[StatusSwap] = 1
-	end := ev.Rank() + 130 + RandInt(41)
-	g.PushEvent(&simpleEvent{ERank: end, EAction: SwapEnd})
-	g.Player.Expire[StatusSwap] = end
+	g.PutStatus(StatusSwap, ev)
 	g.Printf("You quaff the %s. You feel light-footed.", SwapPotion)
 	return nil
 }
@@ -301,12 +286,8 @@ func (g *game) QuaffShadowsPotion(ev event) error {
 	if g.Player.HasStatus(StatusShadows) {
 		return errors.New("You are already surrounded by shadows.")
 	}
-	g.Player.Statuses[StatusShadows] = 1
-	end := ev.Rank() + 130 + RandInt(41)
-	g.PushEvent(&simpleEvent{ERank: end, EAction: ShadowsEnd})
-	g.Player.Expire[StatusShadows] = end
+	g.PutStatus(StatusShadows, ev)
 	g.Printf("You quaff the %s. You feel surrounded by shadows.", ShadowsPotion)
-	g.ComputeLOS()
 	return nil
 }
 
@@ -368,10 +349,7 @@ func (g *game) QuaffTormentPotion(ev event) error {
 }
 
 func (g *game) QuaffAccuracyPotion(ev event) error {
-	g.Player.Statuses[StatusAccurate]++
-	end := ev.Rank() + 85 + RandInt(20)
-	g.PushEvent(&simpleEvent{ERank: end, EAction: AccurateEnd})
-	g.Player.Expire[StatusAccurate] = end
+	g.PutStatus(StatusAccurate, ev)
 	g.Printf("You quaff the %s. You feel accurate.", SwiftnessPotion)
 	return nil
 }
@@ -421,9 +399,10 @@ const (
 	SlowingMagara
 	ConfuseMagara
 	NightMagara
+	DiscordMagara
 )
 
-const NumProjectiles = int(NightMagara) + 1
+const NumProjectiles = int(DiscordMagara) + 1
 
 func (p projectile) String() (text string) {
 	switch p {
@@ -439,6 +418,8 @@ func (p projectile) String() (text string) {
 		text = "confusion magara"
 	case NightMagara:
 		text = "night magara"
+	case DiscordMagara:
+		text = "discord magara"
 	}
 	return text
 }
@@ -457,6 +438,8 @@ func (p projectile) Plural() (text string) {
 		text = "confusion magaras"
 	case NightMagara:
 		text = "night magaras"
+	case DiscordMagara:
+		text = "discord magaras"
 	}
 	return text
 }
@@ -475,6 +458,8 @@ func (p projectile) Desc() (text string) {
 		text = "generates a harmonic light that confuses all the monsters in your line of sight."
 	case NightMagara:
 		text = "can be thrown at a monster to produce sleep inducing clouds in a 2-radius area. You are affected too by the clouds, but they will slow your actions instead."
+	case DiscordMagara:
+		text = "generates a discordant sound that enrages all the monsters in your line of sight, making them attack the nearest creature, friend or foe."
 	}
 	return fmt.Sprintf("The %s %s", p, text)
 }
@@ -507,6 +492,8 @@ func (p projectile) Use(g *game, ev event) error {
 		err = g.ThrowConfuseMagara(ev)
 	case NightMagara:
 		err = g.ThrowNightMagara(ev)
+	case DiscordMagara:
+		err = g.ThrowDiscordMagara(ev)
 	}
 	if err != nil {
 		return err
@@ -538,7 +525,7 @@ func (g *game) ThrowConfusingDart(ev event) error {
 	} else {
 		g.PrintfStyled("Your %s kills the %s.", logPlayerHit, ConfusingDart, mons.Kind)
 		g.ui.ThrowAnimation(g.Ray(mons.P), true)
-		g.HandleKill(mons, ev)
+		g.HandleKill(mons, KillByPlayer, ev)
 	}
 	g.HandleStone(mons)
 	ev.Renew(g, 7)
@@ -558,6 +545,7 @@ func (g *game) ExplosionAt(ev event, p gruid.Point) {
 		mons.MakeHuntIfHurt(g)
 	} else if g.Dungeon.Cell(p).T == WallCell && RandInt(2) == 0 {
 		g.Dungeon.SetCell(p, FreeCell)
+		g.InvalidatePathMaps()
 		g.Stats.Digs++
 		if !g.Player.LOS[p] {
 			g.WrongWall[p] = true
@@ -596,7 +584,7 @@ func (g *game) ThrowTeleportMagara(ev event) error {
 	for _, p := range append(neighbors, g.Player.Target) {
 		mons := g.MonsterAt(p)
 		if mons.Exists() {
-			mons.TeleportAway(g)
+			mons.TeleportAway(g, ev)
 		}
 	}
 
@@ -617,8 +605,7 @@ func (g *game) ThrowSlowingMagara(ev event) error {
 		if !mons.Exists() {
 			continue
 		}
-		mons.Statuses[MonsSlow]++
-		g.PushEvent(&monsterEvent{ERank: g.Ev.Rank() + 130 + RandInt(40), NMons: mons.Index, EAction: MonsSlowEnd})
+		mons.PutStatusFor(g, StatusSlow, 130+RandInt(40), ev)
 	}
 
 	ev.Renew(g, 7)
@@ -641,6 +628,23 @@ func (g *game) ThrowConfuseMagara(ev event) error {
 	return nil
 }
 
+func (g *game) ThrowDiscordMagara(ev event) error {
+	g.Printf("You activate the %s. A discordant sound enrages monsters.", DiscordMagara)
+	for p, b := range g.Player.LOS {
+		if !b {
+			continue
+		}
+		mons := g.MonsterAt(p)
+		if mons.Exists() {
+			mons.EnterEnrage(g, ev)
+		}
+	}
+	g.MakeNoise(MagicCastNoise, g.Player.P)
+
+	ev.Renew(g, 7)
+	return nil
+}
+
 func (g *game) NightFog(at gruid.Point, radius int, ev event) {
 	dij := &normalPath{game: g}
 	nodes := g.PR.BreadthFirstMap(dij, []gruid.Point{at}, radius)
@@ -685,6 +689,7 @@ var ConsumablesCollectData = map[consumable]collectData{
 	TeleportMagara:      {rarity: 12, quantity: 1},
 	SlowingMagara:       {rarity: 12, quantity: 1},
 	ConfuseMagara:       {rarity: 15, quantity: 1},
+	DiscordMagara:       {rarity: 15, quantity: 1},
 	TeleportationPotion: {rarity: 6, quantity: 1},
 	BerserkPotion:       {rarity: 6, quantity: 1},
 	HealWoundsPotion:    {rarity: 6, quantity: 1},
@@ -832,9 +837,11 @@ const (
 	DragonSabre
 	FinalBlade
 	DefenderFlail
+	ViperDagger
+	SerratedSabre
 )
 
-const WeaponNum = int(DefenderFlail) + 1
+const WeaponNum = int(SerratedSabre) + 1
 
 func (wp weapon) Equip(g *game) {
 	owp := g.Player.Weapon
@@ -882,6 +889,10 @@ func (wp weapon) String() string {
 		return "final blade"
 	case DefenderFlail:
 		return "defender flail"
+	case ViperDagger:
+		return "viper dagger"
+	case SerratedSabre:
+		return "serrated sabre"
 	default:
 		// should not happen
 		return "some weapon"
@@ -920,6 +931,10 @@ func (wp weapon) Short() string {
 		return "Fn"
 	case DefenderFlail:
 		return "Fl"
+	case ViperDagger:
+		return "Vp"
+	case SerratedSabre:
+		return "Sr"
 	default:
 		// should not happen
 		return "?"
@@ -959,13 +974,17 @@ func (wp weapon) Desc() string {
 		text = "The final blade is an accurate two-handed weapon that instantly kills monsters at less than half full health. Wielding this weapon will reduce your maximum health by a third."
 	case DefenderFlail:
 		text = "The defender flail is a one-handed weapon that moves foes toward you, and hits harder as you keep attacking without moving."
+	case ViperDagger:
+		text = "The viper dagger is a one-handed weapon whose venomous blade poisons living monsters on hit. Poison deals damage over time, and stacks."
+	case SerratedSabre:
+		text = "The serrated sabre is a one-handed weapon that can make living monsters bleed, dealing damage over time."
 	}
 	return fmt.Sprintf("%s It can hit for up to %d damage.", text, wp.Attack())
 }
 
 func (wp weapon) Attack() int {
 	switch wp {
-	case Axe, Spear, AssassinSabre, DancingRapier, DragonSabre:
+	case Axe, Spear, AssassinSabre, DancingRapier, DragonSabre, SerratedSabre:
 		return 11
 	case BattleAxe, Halberd, HopeSword, FinalBlade:
 		return 15
@@ -975,7 +994,7 @@ func (wp weapon) Attack() int {
 		return 14
 	case DefenderFlail:
 		return 10
-	case Dagger, VampDagger:
+	case Dagger, VampDagger, ViperDagger:
 		return 9
 	case ElecWhip:
 		return 8
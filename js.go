@@ -313,6 +313,38 @@ func (g *game) WriteDump() error {
 	return nil
 }
 
+func (g *game) WriteCombatLog() error {
+	pre := js.Global().Get("document").Call("getElementById", "dump")
+	pre.Set("innerHTML", g.CombatLogText())
+	return nil
+}
+
+func (g *game) RecordDailyScore() error {
+	storage := js.Global().Get("localStorage")
+	if storage.Type() != js.TypeObject {
+		return errors.New("localStorage not found")
+	}
+	scores := storage.Call("getItem", "boohudailyscores")
+	s := ""
+	if scores.Type() == js.TypeString {
+		s = scores.String()
+	}
+	storage.Call("setItem", "boohudailyscores", s+g.NewDailyScore("you").String()+"\n")
+	return nil
+}
+
+func (g *game) DailyScores() ([]dailyScore, error) {
+	storage := js.Global().Get("localStorage")
+	if storage.Type() != js.TypeObject {
+		return nil, errors.New("localStorage not found")
+	}
+	scores := storage.Call("getItem", "boohudailyscores")
+	if scores.Type() != js.TypeString {
+		return nil, nil
+	}
+	return parseDailyScores([]byte(scores.String()), g.Opts.Daily)
+}
+
 // End of io compatibility functions
 
 func (ui *gameui) Init() error {
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"codeberg.org/anaseto/gruid"
+)
+
+// level stores the state of a level the player left, so that it can be
+// restored when coming back (see ChangeLevel).
+type level struct {
+	Dungeon          *dungeon
+	Monsters         []*monster
+	MonstersPosCache []int
+	Bands            []monsterBand
+	BandData         []monsterBandData
+	Collectables     map[gruid.Point]collectable
+	Equipables       map[gruid.Point]equipable
+	Rods             map[gruid.Point]rod
+	Stairs           map[gruid.Point]stair
+	Clouds           map[gruid.Point]cloud
+	Fungus           map[gruid.Point]vegetation
+	Doors            map[gruid.Point]bool
+	Features         map[gruid.Point]feature
+	MerchantStock    []ware
+	DoorStates       map[gruid.Point]doorState
+	Keys             map[gruid.Point]bool
+	Levers           map[gruid.Point]lever
+	Vault            map[gruid.Point]bool
+	WoodenFloor      map[gruid.Point]bool
+	Darkness         map[gruid.Point]bool
+	Lights           map[gruid.Point]light
+	TemporalWalls    map[gruid.Point]bool
+	MagicalStones    map[gruid.Point]stone
+	Traps            map[gruid.Point]trap
+	KnownTraps       map[gruid.Point]bool
+	Simellas         map[gruid.Point]int
+	WrongWall        map[gruid.Point]bool
+	WrongFoliage     map[gruid.Point]bool
+	WrongDoor        map[gruid.Point]bool
+	ExclusionsMap    map[gruid.Point]bool
+	DreamingMonster  map[gruid.Point]bool
+	CloudEvents      []event     // pending cloud events
+	PlayerP          gruid.Point // stairs taken to leave the level
+	Turn             int         // when the level was left
+}
+
+// StoreLevel saves the current level state. It should be called before
+// leaving the level, after cleaning the event queue.
+func (g *game) StoreLevel(cevs []event) {
+	if g.Levels == nil {
+		g.Levels = map[levelID]*level{}
+	}
+	g.Levels[g.LevelID()] = &level{
+		Dungeon:          g.Dungeon,
+		Monsters:         g.Monsters,
+		MonstersPosCache: g.MonstersPosCache,
+		Bands:            g.Bands,
+		BandData:         g.BandData,
+		Collectables:     g.Collectables,
+		Equipables:       g.Equipables,
+		Rods:             g.Rods,
+		Stairs:           g.Stairs,
+		Clouds:           g.Clouds,
+		Fungus:           g.Fungus,
+		Doors:            g.Doors,
+		Features:         g.Features,
+		MerchantStock:    g.MerchantStock,
+		DoorStates:       g.DoorStates,
+		Keys:             g.Keys,
+		Levers:           g.Levers,
+		Vault:            g.Vault,
+		WoodenFloor:      g.WoodenFloor,
+		Darkness:         g.Darkness,
+		Lights:           g.Lights,
+		TemporalWalls:    g.TemporalWalls,
+		MagicalStones:    g.MagicalStones,
+		Traps:            g.Traps,
+		KnownTraps:       g.KnownTraps,
+		Simellas:         g.Simellas,
+		WrongWall:        g.WrongWall,
+		WrongFoliage:     g.WrongFoliage,
+		WrongDoor:        g.WrongDoor,
+		ExclusionsMap:    g.ExclusionsMap,
+		DreamingMonster:  g.DreamingMonster,
+		CloudEvents:      cevs,
+		PlayerP:          g.Player.P,
+		Turn:             g.Turn,
+	}
+}
+
+// RestoreLevel restores the state of the current level, if it was stored
+// before. It returns false if the level has to be generated.
+func (g *game) RestoreLevel() bool {
+	id := g.LevelID()
+	l, ok := g.Levels[id]
+	if !ok {
+		return false
+	}
+	delete(g.Levels, id)
+	g.Dungeon = l.Dungeon
+	g.Monsters = l.Monsters
+	g.MonstersPosCache = l.MonstersPosCache
+	g.Bands = l.Bands
+	g.BandData = l.BandData
+	g.Collectables = l.Collectables
+	g.Equipables = l.Equipables
+	g.Rods = l.Rods
+	g.Stairs = l.Stairs
+	g.Clouds = l.Clouds
+	g.Fungus = l.Fungus
+	g.Doors = l.Doors
+	g.Features = l.Features
+	g.MerchantStock = l.MerchantStock
+	g.DoorStates = l.DoorStates
+	g.Keys = l.Keys
+	g.Levers = l.Levers
+	g.Vault = l.Vault
+	g.WoodenFloor = l.WoodenFloor
+	g.Darkness = l.Darkness
+	g.Lights = l.Lights
+	g.ComputeLight()
+	g.TemporalWalls = l.TemporalWalls
+	g.MagicalStones = l.MagicalStones
+	g.Traps = l.Traps
+	g.KnownTraps = l.KnownTraps
+	g.Simellas = l.Simellas
+	g.WrongWall = l.WrongWall
+	g.WrongFoliage = l.WrongFoliage
+	g.WrongDoor = l.WrongDoor
+	g.ExclusionsMap = l.ExclusionsMap
+	g.DreamingMonster = l.DreamingMonster
+	g.Player.P = l.PlayerP
+	g.Noise = map[gruid.Point]bool{}
+	g.Scent = map[gruid.Point]int{}
+	g.HeardNoise = map[gruid.Point]heardNoise{}
+	g.InvalidatePathMaps()
+	g.CatchUpCloudEvents(l.CloudEvents)
+	g.MonstersPassTime((g.Turn - l.Turn) / 10)
+	for i := range g.Monsters {
+		g.PushEvent(&monsterEvent{ERank: g.Turn + RandInt(10), EAction: MonsterTurn, NMons: i})
+	}
+	g.PrintStyled("You come back to a level you already visited.", logSpecial)
+	g.ComputeLOS()
+	g.MakeMonstersAware()
+	return true
+}
+
+// CatchUpCloudEvents pushes again the cloud events of a restored level.
+// Events that should have happened while the player was away are resolved
+// immediately in a simplified way: clouds vanish and temporal walls
+// disappear.
+func (g *game) CatchUpCloudEvents(cevs []event) {
+	for _, ev := range cevs {
+		cev, ok := ev.(*cloudEvent)
+		if !ok {
+			continue
+		}
+		if cev.Rank() > g.Turn {
+			g.PushEvent(cev)
+			continue
+		}
+		switch cev.EAction {
+		case CloudEnd, FireProgression, NightProgression, GasProgression:
+			delete(g.Clouds, cev.P)
+		case ObstructionEnd:
+			delete(g.TemporalWalls, cev.P)
+			if g.Dungeon.Cell(cev.P).T == WallCell {
+				g.Dungeon.SetCell(cev.P, FreeCell)
+			}
+		case ObstructionProgression:
+			g.PushEvent(&cloudEvent{ERank: g.Turn + 200 + RandInt(50), EAction: ObstructionProgression})
+		}
+	}
+}
+
+// MonstersPassTime applies the effects of the given number of turns passing
+// to the monsters of a level the player was away from.
+func (g *game) MonstersPassTime(turns int) {
+	for _, mons := range g.Monsters {
+		if !mons.Exists() {
+			continue
+		}
+		// status end events were lost with the level
+		mons.Statuses = [NumStatuses]int{}
+		mons.Path = nil
+		if turns >= 100 {
+			mons.HP = mons.HPmax
+		} else {
+			mons.HP = Min(mons.HPmax, mons.HP+turns/10)
+		}
+		switch mons.State {
+		case Hunting, Fleeing:
+			// the player is long gone
+			mons.State = Wandering
+			mons.Target = mons.P
+		case Resting:
+			if RandInt(200) < turns {
+				mons.State = Wandering
+				mons.Target = mons.P
+			}
+		}
+		if mons.State == Wandering && mons.Behaviour == BehaveWander && RandInt(100) < turns {
+			// the monster wandered elsewhere
+			mons.PlaceAt(g, g.FreeCell())
+		}
+		if mons.P == g.Player.P {
+			mons.PlaceAt(g, g.FreeCell())
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import "codeberg.org/anaseto/gruid"
+
+// light is a kind of light source lighting dark areas.
+type light int
+
+const (
+	Brazier light = iota
+	GlowingFungus
+)
+
+func (l light) String() (text string) {
+	switch l {
+	case Brazier:
+		text = "brazier"
+	case GlowingFungus:
+		text = "glowing fungus"
+	}
+	return text
+}
+
+func (l light) Description() (text string) {
+	switch l {
+	case Brazier:
+		text = "A brazier lights the surrounding area, revealing anyone standing there."
+	case GlowingFungus:
+		text = "Some foliage covered with faintly glowing fungi, lighting its close surroundings. It is flammable."
+	}
+	return text
+}
+
+// Radius returns the light radius of the light source.
+func (l light) Radius() int {
+	switch l {
+	case Brazier:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// GenLighting generates dark areas for the level, and light sources in
+// them.
+func (g *game) GenLighting() {
+	g.Darkness = map[gruid.Point]bool{}
+	g.Lights = map[gruid.Point]light{}
+	if g.Depth > 1 {
+		nzones := RandInt(2 + g.DangerDepth()/4)
+		dij := &normalPath{game: g}
+		for i := 0; i < nzones; i++ {
+			nodes := g.PR.BreadthFirstMap(dij, []gruid.Point{g.FreeCellForStatic()}, 4+RandInt(4))
+			for _, n := range nodes {
+				g.Darkness[n.P] = true
+			}
+			if RandInt(2) == 0 {
+				continue
+			}
+			for j := 0; j < 50; j++ {
+				p := g.FreeCellForStatic()
+				if g.Darkness[p] {
+					g.Lights[p] = Brazier
+					break
+				}
+			}
+		}
+		for i := range g.Dungeon.Cells {
+			p := idx2Point(i)
+			if _, ok := g.Fungus[p]; ok && g.Darkness[p] && RandInt(8) == 0 {
+				g.Lights[p] = GlowingFungus
+			}
+		}
+	}
+	g.ComputeLight()
+}
+
+// ComputeLight computes the positions lit by light sources.
+func (g *game) ComputeLight() {
+	g.Lighted = map[gruid.Point]bool{}
+	dij := &normalPath{game: g}
+	for p, l := range g.Lights {
+		nodes := g.PR.BreadthFirstMap(dij, []gruid.Point{p}, l.Radius())
+		for _, n := range nodes {
+			g.Lighted[n.P] = true
+		}
+	}
+}
+
+// RemoveLight removes the light source at a given position, if any.
+func (g *game) RemoveLight(p gruid.Point) {
+	if _, ok := g.Lights[p]; !ok {
+		return
+	}
+	delete(g.Lights, p)
+	g.ComputeLight()
+}
+
+// Dark reports whether a position is in darkness. Dark areas are lit by light
+// sources and by nearby flames.
+func (g *game) Dark(p gruid.Point) bool {
+	if !g.Darkness[p] || g.Lighted[p] {
+		return false
+	}
+	if cld, ok := g.Clouds[p]; ok && cld == CloudFire {
+		return false
+	}
+	for _, q := range g.Dungeon.FreeNeighbors(p) {
+		if cld, ok := g.Clouds[q]; ok && cld == CloudFire {
+			return false
+		}
+	}
+	return true
+}
+
+// DarkVisionRange returns the distance up to which the player can see in
+// darkness.
+func (g *game) DarkVisionRange() int {
+	if g.Player.Aptitudes[AptStealthyLOS] {
+		return 3
+	}
+	return 1
+}
+
+// HiddenInDarkness reports whether darkness prevents a monster from noticing
+// the player.
+func (g *game) HiddenInDarkness(m *monster) bool {
+	if !g.Dark(g.Player.P) {
+		return false
+	}
+	d := Distance(m.P, g.Player.P)
+	switch {
+	case g.Player.HasStatus(StatusShadows):
+		return d > 1 || RandInt(2) == 0
+	case g.Player.Aptitudes[AptStealthyLOS]:
+		return d > 1
+	default:
+		return d > 2
+	}
+}
@@ -12,6 +12,7 @@ const (
 	logSpecial
 	logStatusEnd
 	logError
+	logCombat
 )
 
 type logEntry struct {
@@ -29,10 +29,10 @@ func (lt *lighter) Cost(src, from, to gruid.Point) int {
 	if c.T == WallCell {
 		return wallcost
 	}
-	if _, ok := g.Clouds[from]; ok {
+	if cld, ok := g.Clouds[from]; ok && cld.Opaque() {
 		return wallcost
 	}
-	if _, ok := g.Doors[from]; ok {
+	if g.ClosedDoor(from) {
 		if from != src {
 			mons := g.MonsterAt(from)
 			if !mons.Exists() && from != g.Player.P {
@@ -123,6 +123,9 @@ func (g *game) ComputeLOS() {
 		}
 		if n.Cost <= losRange {
 			p := n.P
+			if g.Dark(p) && Distance(p, g.Player.P) > g.DarkVisionRange() {
+				continue
+			}
 			g.Player.LOS[p] = true
 			g.SeePosition(p)
 		}
@@ -168,17 +171,17 @@ func (g *game) SeePosition(p gruid.Point) {
 		}
 		g.FunAction()
 		g.Dungeon.SetExplored(p)
-		g.DijkstraMapRebuild = true
+		g.InvalidatePathMaps()
 	} else {
 		if g.WrongWall[p] {
 			g.Printf("There is no longer a wall there.")
 			g.StopAuto()
-			g.DijkstraMapRebuild = true
+			g.InvalidatePathMaps()
 		}
 		if cld, ok := g.Clouds[p]; ok && cld == CloudFire && (g.WrongDoor[p] || g.WrongFoliage[p]) {
 			g.Printf("There are flames there.")
 			g.StopAuto()
-			g.DijkstraMapRebuild = true
+			g.InvalidatePathMaps()
 		}
 	}
 	if g.WrongWall[p] {
@@ -234,7 +237,8 @@ func (g *game) ComputeRayHighlight(p gruid.Point) {
 }
 
 func (g *game) ComputeNoise() {
-	dij := &noisePath{game: g}
+	g.ForgetHeardNoises()
+	dij := &soundPath{game: g}
 	rg := g.LosRange() + 2
 	if rg <= 5 {
 		rg++
@@ -242,7 +246,7 @@ func (g *game) ComputeNoise() {
 	if g.Player.Aptitudes[AptHear] {
 		rg++
 	}
-	nodes := g.PR.BreadthFirstMap(dij, []gruid.Point{g.Player.P}, rg)
+	nodes := g.PR.DijkstraMap(dij, []gruid.Point{g.Player.P}, rg)
 	count := 0
 	noise := map[gruid.Point]bool{}
 	rmax := 3
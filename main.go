@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"runtime"
+	"time"
 )
 
 func main() {
@@ -23,6 +24,11 @@ func main() {
 	opt256colors := flag.Bool("x", !color8, "use xterm 256-color palette (solarized approximation)")
 	optNoAnim := flag.Bool("n", false, "no animations")
 	optReplay := flag.String("r", "", "path to replay file")
+	optDaily := flag.Bool("d", false, "play today's daily challenge")
+	optEndless := flag.Bool("e", false, "endless mode: levels continue past the bottom")
+	optRevisit := flag.Bool("u", false, "revisitable levels with up stairs")
+	optRandomApt := flag.Bool("t", false, "traditional mode: aptitudes are gained randomly instead of chosen")
+	optStartApt := flag.Bool("a", false, "gain an aptitude at the start of the game")
 	flag.Parse()
 	if *optSolarized {
 		SolarizedPalette()
@@ -52,6 +58,23 @@ func main() {
 	ui := &gameui{}
 	g := &game{}
 	ui.g = g
+	if *optDaily {
+		g.Opts.Daily = DailyDate(time.Now())
+		err := g.CheckDaily()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "boohu: %v\n", err)
+			os.Exit(1)
+		}
+		if *optEndless || *optRevisit || *optRandomApt || *optStartApt {
+			fmt.Fprintf(os.Stderr, "boohu: game options are ignored in the daily challenge\n")
+		}
+	} else {
+		// the daily run is the same for everyone, without options
+		g.Opts.Endless = *optEndless
+		g.Opts.Revisit = *optRevisit
+		g.Opts.RandomAptitudes = *optRandomApt
+		g.Opts.StartAptitude = *optStartApt
+	}
 	err := ui.Init()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "boohu: %v\n", err)
@@ -79,9 +102,9 @@ func main() {
 	ui.DrawWelcome()
 	load, err = g.Load()
 	if !load {
-		g.InitLevel()
+		g.NewGame()
 	} else if err != nil {
-		g.InitLevel()
+		g.NewGame()
 		g.PrintfStyled("Error: %v", logError, err)
 		g.PrintStyled("Could not load saved game… starting new game.", logError)
 	} else {
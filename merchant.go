@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// wareKind is a kind of merchandise sold by merchants in exchange for
+// simellas.
+type wareKind int
+
+const (
+	WarePotion wareKind = iota
+	WareProjectile
+	WareRecharge
+	WareAptitude
+)
+
+const NumWareKinds = int(WareAptitude) + 1
+
+type wareData struct {
+	name     string
+	price    int // base price in simellas
+	perDepth int // price increase per level of danger depth
+	stock    int // number of wares of this kind offered by a merchant
+}
+
+// WareData describes the prices and availability of the different kinds of
+// wares.
+var WareData = [NumWareKinds]wareData{
+	WarePotion:     {name: "potion", price: 8, perDepth: 2, stock: 3},
+	WareProjectile: {name: "projectile", price: 5, perDepth: 1, stock: 2},
+	WareRecharge:   {name: "rod recharge", price: 12, perDepth: 2, stock: 1},
+	WareAptitude:   {name: "aptitude", price: 35, perDepth: 4, stock: 1},
+}
+
+func (wk wareKind) String() string {
+	return WareData[wk].name
+}
+
+// ware is an item sold by a merchant.
+type ware struct {
+	Kind wareKind
+	Item consumable // for potions and projectiles
+	Sold bool
+}
+
+func (w ware) String() (text string) {
+	switch w.Kind {
+	case WarePotion, WareProjectile:
+		text = w.Item.String()
+	case WareRecharge:
+		text = "full recharge of your rods"
+	case WareAptitude:
+		text = "teaching of a new aptitude"
+	}
+	return text
+}
+
+// WarePrice returns the price of a ware in the current level.
+func (g *game) WarePrice(w ware) int {
+	wd := WareData[w.Kind]
+	return wd.price + wd.perDepth*g.DangerDepth()
+}
+
+// GenMerchantStock generates the wares offered by a merchant.
+func (g *game) GenMerchantStock() {
+	g.MerchantStock = []ware{}
+	for i := 0; i < NumWareKinds; i++ {
+		wk := wareKind(i)
+		for j := 0; j < WareData[wk].stock; j++ {
+			w := ware{Kind: wk}
+			switch wk {
+			case WarePotion:
+				w.Item = potion(RandInt(NumPotions))
+			case WareProjectile:
+				w.Item = projectile(RandInt(NumProjectiles))
+			}
+			g.MerchantStock = append(g.MerchantStock, w)
+		}
+	}
+}
+
+// Trade makes the player buy a ware from the merchant.
+func (g *game) Trade(ev event) error {
+	wares := []int{}
+	for i, w := range g.MerchantStock {
+		if !w.Sold {
+			wares = append(wares, i)
+		}
+	}
+	if len(wares) == 0 {
+		return errors.New("The merchant has nothing left to sell.")
+	}
+	i, err := g.ui.SelectWare(wares)
+	if err != nil {
+		return err
+	}
+	w := g.MerchantStock[i]
+	price := g.WarePrice(w)
+	if g.Player.Simellas < price {
+		return fmt.Errorf("You need %d simellas to buy this.", price)
+	}
+	name := Indefinite(w.Kind.String(), false)
+	switch w.Kind {
+	case WarePotion, WareProjectile:
+		name = Indefinite(w.Item.String(), false)
+		g.Player.Consumables[w.Item]++
+		g.Printf("You buy %s for %d simellas.", name, price)
+	case WareRecharge:
+		if len(g.Player.Rods) == 0 {
+			return errors.New("You do not have any rods.")
+		}
+		if g.RodsCharged() {
+			return errors.New("Your rods are already fully charged.")
+		}
+		g.RefillRods()
+		g.Printf("You pay %d simellas. The merchant recharges your rods.", price)
+	case WareAptitude:
+		apts := g.OfferedAptitudes(3)
+		if len(apts) == 0 {
+			return errors.New("The merchant has nothing more to teach you.")
+		}
+		apt, err := g.ui.SelectAptitude(apts, false)
+		if err != nil {
+			return err
+		}
+		g.Printf("You pay %d simellas for the teaching of the merchant.", price)
+		g.ApplyAptitude(apt)
+		g.StoryPrintf("Gained aptitude: %s", apt)
+	}
+	w.Sold = true
+	g.MerchantStock[i] = w
+	g.Player.Simellas -= price
+	g.Stats.SpentSimellas += price
+	g.Stats.Purchases[w.Kind]++
+	g.StoryPrintf("Bought %s for %d simellas.", name, price)
+	ev.Renew(g, 10)
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"sort"
 
 	"codeberg.org/anaseto/gruid"
 )
@@ -12,6 +13,7 @@ const (
 	Resting monsterState = iota
 	Hunting
 	Wandering
+	Fleeing
 )
 
 func (m monsterState) String() string {
@@ -23,35 +25,12 @@ func (m monsterState) String() string {
 		st = "wandering"
 	case Hunting:
 		st = "hunting"
+	case Fleeing:
+		st = "fleeing"
 	}
 	return st
 }
 
-type monsterStatus int
-
-const (
-	MonsConfused monsterStatus = iota
-	MonsExhausted
-	MonsSlow
-	MonsLignified
-)
-
-const NMonsStatus = int(MonsLignified) + 1
-
-func (st monsterStatus) String() (text string) {
-	switch st {
-	case MonsConfused:
-		text = "confused"
-	case MonsExhausted:
-		text = "exhausted"
-	case MonsSlow:
-		text = "slowed"
-	case MonsLignified:
-		text = "lignified"
-	}
-	return text
-}
-
 type monsterKind int
 
 const (
@@ -81,6 +60,7 @@ const (
 	MonsVampire
 	MonsTreeMushroom
 	MonsMarevorHelith
+	MonsCaveViper
 )
 
 func (mk monsterKind) String() string {
@@ -216,6 +196,7 @@ var MonsData = []monsterData{
 	MonsVampire:         {10, 9, 10, 21, 17, 0, 15, 'V', "vampire", 13},
 	MonsTreeMushroom:    {12, 15, 12, 38, 14, 4, 6, 'T', "tree mushroom", 17},
 	MonsMarevorHelith:   {10, 0, 10, 97, 18, 10, 15, 'M', "Marevor Helith", 18},
+	MonsCaveViper:       {8, 7, 10, 14, 15, 0, 14, 'v', "cave viper", 5},
 }
 
 var monsDesc = []string{
@@ -231,8 +212,8 @@ var monsDesc = []string{
 	MonsGiantBee:        "Giant bees are fragile but extremely fast moving creatures. Their bite can sometimes enrage you.",
 	MonsGoblinWarrior:   "Goblin warriors are goblins that learned to fight, and got equipped with leather armour. They can throw javelins.",
 	MonsHydra:           "Hydras are enormous creatures with four heads that can hit you each at once.",
-	MonsSkeletonWarrior: "Skeleton warriors are good fighters, clad in chain mail.",
-	MonsSpider:          "Spiders are fast moving fragile creatures, whose bite can confuse you.",
+	MonsSkeletonWarrior: "Skeleton warriors are good fighters, clad in chain mail. Their rusty blades can make you bleed.",
+	MonsSpider:          "Spiders are fast moving fragile creatures, whose bite can confuse or poison you.",
 	MonsWingedMilfid:    "Winged milfids are fast moving humanoids that can fly over you and make you swap positions. They tend to be very agressive creatures.",
 	MonsBlinkingFrog:    "Blinking frogs are big frog-like creatures, whose bite can make you blink away.",
 	MonsLich:            "Liches are non-living mages wearing a leather armour. They can throw a bolt of torment at you, halving your HP.",
@@ -245,6 +226,7 @@ var monsDesc = []string{
 	MonsVampire:         "Vampires are humanoids that drink blood to survive. Their spitting can cause nausea, impeding the use of potions.",
 	MonsTreeMushroom:    "Tree mushrooms are big clunky slow-moving creatures. They can throw lignifying spores at you.",
 	MonsMarevorHelith:   "Marevor Helith is an ancient undead nakrus very fond of teleporting people away. He is a well-known expert in the field of magaras - items that many people simply call magical objects. His current research focus is monolith creation. Marevor, a repentant necromancer, is now searching for his old disciple Jaixel in the Underground to help him overcome the past.",
+	MonsCaveViper:       "Cave vipers are fast moving snakes that hide in the dark corners of the Underground. Their bite is poisonous.",
 }
 
 type monsterBand int
@@ -354,6 +336,8 @@ const (
 	UXMilfidYack
 	UXYacks
 	UXVariedWarriors
+	LoneCaveViper
+	BandCaveVipers
 )
 
 type monsInterval struct {
@@ -369,23 +353,39 @@ type monsterBandData struct {
 	Band         bool
 	Monster      monsterKind
 	Unique       bool
+	Tactics      bandTactics
+	Behaviour    monsterBehaviour
+}
+
+// BandDepth returns the depth used for band depth limits. In endless mode,
+// levels past MaxDepth use the bands available at MaxDepth.
+func (g *game) BandDepth() int {
+	return Min(g.DangerDepth(), MaxDepth)
 }
 
 func (g *game) GenBand(mbd monsterBandData, band monsterBand) []monsterKind {
 	if g.GeneratedUniques[band] > 0 && mbd.Unique {
 		return nil
 	}
-	if g.Depth > mbd.MaxDepth {
+	depth := g.BandDepth()
+	if depth > mbd.MaxDepth {
 		return nil
 	}
-	if g.Depth < mbd.MinDepth {
+	if depth < mbd.MinDepth {
 		return nil
 	}
 	if !mbd.Band {
 		return []monsterKind{mbd.Monster}
 	}
+	kinds := []monsterKind{}
+	for m := range mbd.Distribution {
+		kinds = append(kinds, m)
+	}
+	// stable order, so that generation only depends on the random seed
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
 	bandMonsters := []monsterKind{}
-	for m, interval := range mbd.Distribution {
+	for _, m := range kinds {
+		interval := mbd.Distribution[m]
 		for i := 0; i < interval.Min+RandInt(interval.Max-interval.Min+1); i++ {
 			bandMonsters = append(bandMonsters, m)
 		}
@@ -432,6 +432,8 @@ var MonsBands = []monsterBandData{
 	BandGoblinsMany: {
 		Distribution: map[monsterKind]monsInterval{MonsGoblin: {4, 4}},
 		Rarity:       7, MinDepth: 2, MaxDepth: 3, Band: true,
+		Tactics:   TacticSurround,
+		Behaviour: BehavePatrol,
 	},
 	BandGoblinsHound: {
 		Distribution: map[monsterKind]monsInterval{MonsGoblin: {2, 2}, MonsHound: {1, 1}},
@@ -446,6 +448,7 @@ var MonsBands = []monsterBandData{
 			MonsGoblin:        {3, 3},
 			MonsGoblinWarrior: {2, 2}},
 		Rarity: 7, MinDepth: 4, MaxDepth: 5, Band: true,
+		Tactics: TacticSurround | TacticRangedBehind,
 	},
 	BandGoblinsWithWarriorsMilfid: {
 		Distribution: map[monsterKind]monsInterval{
@@ -453,6 +456,7 @@ var MonsBands = []monsterBandData{
 			MonsGoblinWarrior: {1, 1},
 			MonsWingedMilfid:  {1, 1}},
 		Rarity: 8, MinDepth: 4, MaxDepth: 5, Band: true,
+		Tactics: TacticSurround | TacticRangedBehind,
 	},
 	BandGoblinsWithWarriorsHound: {
 		Distribution: map[monsterKind]monsInterval{
@@ -460,6 +464,7 @@ var MonsBands = []monsterBandData{
 			MonsGoblinWarrior: {1, 1},
 			MonsHound:         {1, 1}},
 		Rarity: 7, MinDepth: 4, MaxDepth: 5, Band: true,
+		Tactics: TacticSurround | TacticRangedBehind,
 	},
 	BandGoblinsWithWarriorsOgre: {
 		Distribution: map[monsterKind]monsInterval{
@@ -467,12 +472,15 @@ var MonsBands = []monsterBandData{
 			MonsGoblinWarrior: {1, 1},
 			MonsOgre:          {1, 1}},
 		Rarity: 7, MinDepth: 4, MaxDepth: 5, Band: true,
+		Tactics: TacticSurround | TacticRangedBehind,
 	},
 	BandGoblinWarriors: {
 		Distribution: map[monsterKind]monsInterval{
 			MonsGoblin:        {1, 1},
 			MonsGoblinWarrior: {3, 3}},
 		Rarity: 10, MinDepth: 6, MaxDepth: WinDepth + 1, Band: true,
+		Tactics:   TacticRangedBehind | TacticAmbush,
+		Behaviour: BehaveGuard,
 	},
 	BandGoblinWarriorsMilfid: {
 		Distribution: map[monsterKind]monsInterval{
@@ -480,18 +488,23 @@ var MonsBands = []monsterBandData{
 			MonsGoblinWarrior: {2, 2},
 			MonsWingedMilfid:  {1, 1}},
 		Rarity: 10, MinDepth: 6, MaxDepth: WinDepth + 1, Band: true,
+		Tactics: TacticRangedBehind | TacticAmbush,
 	},
 	BandHounds: {
 		Distribution: map[monsterKind]monsInterval{MonsHound: {2, 2}, MonsGoblin: {1, 1}},
 		Rarity:       6, MinDepth: 2, MaxDepth: 6, Band: true,
+		Tactics: TacticSurround,
 	},
 	BandHoundsMany: {
 		Distribution: map[monsterKind]monsInterval{MonsHound: {3, 3}},
 		Rarity:       10, MinDepth: 2, MaxDepth: 6, Band: true,
+		Tactics:   TacticSurround,
+		Behaviour: BehavePatrol,
 	},
 	BandSpiders: {
 		Distribution: map[monsterKind]monsInterval{MonsSpider: {2, 3}},
 		Rarity:       4, MinDepth: 4, MaxDepth: WinDepth + 1, Band: true,
+		Behaviour: BehaveLair,
 	},
 	BandSpidersMilfid: {
 		Distribution: map[monsterKind]monsInterval{MonsSpider: {2, 2}, MonsWingedMilfid: {1, 1}},
@@ -659,6 +672,7 @@ var MonsBands = []monsterBandData{
 	UBandOgres: {
 		Distribution: map[monsterKind]monsInterval{MonsOgre: {2, 3}, MonsCyclop: {1, 1}},
 		Rarity:       4, MinDepth: 4, MaxDepth: 4, Band: true, Unique: true,
+		Behaviour: BehaveLair,
 	},
 	UBandGoblins: {
 		Distribution: map[monsterKind]monsInterval{
@@ -667,6 +681,7 @@ var MonsBands = []monsterBandData{
 			MonsHound:         {1, 1},
 		},
 		Rarity: 4, MinDepth: 5, MaxDepth: 5, Band: true, Unique: true,
+		Tactics: TacticSurround | TacticRangedBehind | TacticAmbush,
 	},
 	UBandBeeYacks: {
 		Distribution: map[monsterKind]monsInterval{
@@ -781,7 +796,8 @@ var MonsBands = []monsterBandData{
 			MonsHound:         {2, 2},
 			MonsGoblinWarrior: {3, 3},
 		},
-		Rarity: 6, MinDepth: MaxDepth - 1, MaxDepth: MaxDepth, Band: true, Unique: true,
+		Rarity: 6, MinDepth: MaxDepth - 1, MaxDepth: MaxDepth, Band: true,
+		Tactics: TacticSurround | TacticRangedBehind, Unique: true,
 	},
 	UXSatowalgaNixe: {
 		Distribution: map[monsterKind]monsInterval{
@@ -873,6 +889,11 @@ var MonsBands = []monsterBandData{
 		},
 		Rarity: 6, MinDepth: WinDepth + 1, MaxDepth: MaxDepth, Band: true, Unique: true,
 	},
+	LoneCaveViper: {Rarity: 6, MinDepth: 2, MaxDepth: WinDepth + 1, Monster: MonsCaveViper},
+	BandCaveVipers: {
+		Distribution: map[monsterKind]monsInterval{MonsCaveViper: {2, 3}},
+		Rarity:       10, MinDepth: 4, MaxDepth: WinDepth + 1, Band: true,
+	},
 }
 
 type specialBands struct {
@@ -1672,13 +1693,19 @@ type monster struct {
 	HPmax       int
 	HP          int
 	State       monsterState
-	Statuses    [NMonsStatus]int
+	Statuses    [NumStatuses]int
+	StatusEpoch [NumStatuses]int // incremented when a status is cured
 	P           gruid.Point
 	Target      gruid.Point
 	Path        []gruid.Point // cache
 	Obstructing bool
 	FireReady   bool
 	Seen        bool
+	Behaviour   monsterBehaviour
+	Post        gruid.Point   // guard post or lair
+	Route       []gruid.Point // patrol route
+	RouteIndex  int
+	Ally        bool
 }
 
 func (m *monster) Init() {
@@ -1693,8 +1720,35 @@ func (m *monster) Init() {
 	}
 }
 
-func (m *monster) Status(st monsterStatus) bool {
-	return m.Statuses[st] > 0
+// MovementDelay returns the monster's movement delay, taking its statuses
+// into account.
+func (m *monster) MovementDelay() int {
+	delay := m.Kind.MovementDelay()
+	if m.Status(StatusSwift) {
+		delay -= 3
+	}
+	return m.ActionDelay(delay)
+}
+
+// AttackDelay returns the monster's attack delay, taking its statuses into
+// account.
+func (m *monster) AttackDelay() int {
+	return m.ActionDelay(m.Kind.AttackDelay())
+}
+
+// ActionDelay applies to a base delay the status modifiers that apply both to
+// movement and attacks.
+func (m *monster) ActionDelay(delay int) int {
+	if m.Status(StatusBerserk) {
+		delay -= 3
+	}
+	if m.Status(StatusSlow) {
+		delay += 3
+	}
+	if delay < 3 {
+		delay = 3
+	}
+	return delay
 }
 
 func (m *monster) Exists() bool {
@@ -1702,17 +1756,17 @@ func (m *monster) Exists() bool {
 }
 
 func (m *monster) AlternatePlacement(g *game) *gruid.Point {
-	if m.Status(MonsLignified) {
+	if m.Status(StatusLignification) {
 		return nil
 	}
 	var neighbors []gruid.Point
-	if m.Status(MonsConfused) {
+	if m.Status(StatusConfusion) {
 		neighbors = g.Dungeon.CardinalFreeNeighbors(m.P)
 	} else {
 		neighbors = g.Dungeon.FreeNeighbors(m.P)
 	}
 	for _, p := range neighbors {
-		if Distance(p, g.Player.P) != 1 {
+		if Distance(p, g.Player.P) != 1 || m.DoorBlocks(g, p) {
 			continue
 		}
 		mons := g.MonsterAt(p)
@@ -1745,7 +1799,7 @@ func (m *monster) AlternateConfusedPlacement(g *game) *gruid.Point {
 
 func (m *monster) SafePlacement(g *game) *gruid.Point {
 	var neighbors []gruid.Point
-	if m.Status(MonsConfused) {
+	if m.Status(StatusConfusion) {
 		neighbors = g.Dungeon.CardinalFreeNeighbors(m.P)
 	} else {
 		neighbors = g.Dungeon.FreeNeighbors(m.P)
@@ -1790,11 +1844,11 @@ func (m *monster) TeleportPlayer(g *game, ev event) {
 		g.Teleportation(ev)
 	} else if RandInt(2) == 0 {
 		g.Print("Marevor inadvertently goes into a monolith.")
-		m.TeleportAway(g)
+		m.TeleportAway(g, ev)
 	}
 }
 
-func (m *monster) TeleportAway(g *game) {
+func (m *monster) TeleportAway(g *game, ev event) {
 	p := m.P
 	i := 0
 	count := 0
@@ -1823,13 +1877,16 @@ func (m *monster) TeleportAway(g *game) {
 		g.Printf("%s teleports away.", m.Kind.Definite(true))
 	}
 	opos := m.P
-	m.MoveTo(g, p)
+	m.MoveTo(g, p, ev)
 	if g.Player.LOS[opos] {
 		g.ui.TeleportAnimation(opos, p, false)
 	}
 }
 
-func (m *monster) MoveTo(g *game, p gruid.Point) {
+// MoveTo moves the monster to a given position, triggering any trap there.
+// It returns false if the monster did not stay there alive: a trap may
+// teleport or kill it.
+func (m *monster) MoveTo(g *game, p gruid.Point, ev event) bool {
 	if !g.Player.LOS[m.P] && g.Player.LOS[p] {
 		if !m.Seen {
 			m.Seen = true
@@ -1842,6 +1899,8 @@ func (m *monster) MoveTo(g *game, p gruid.Point) {
 	if recomputeLOS {
 		g.ComputeLOS()
 	}
+	g.MonsterTriggerTrap(m, ev)
+	return m.Exists() && m.P == p
 }
 
 func (m *monster) PlaceAt(g *game, p gruid.Point) {
@@ -1850,7 +1909,7 @@ func (m *monster) PlaceAt(g *game, p gruid.Point) {
 	g.MonstersPosCache[idx(m.P)] = m.Index + 1
 }
 
-func (m *monster) TeleportMonsterAway(g *game) bool {
+func (m *monster) TeleportMonsterAway(g *game, ev event) bool {
 	neighbors := g.Dungeon.FreeNeighbors(m.P)
 	for _, p := range neighbors {
 		if p == m.P || RandInt(3) != 0 {
@@ -1861,7 +1920,7 @@ func (m *monster) TeleportMonsterAway(g *game) bool {
 			if g.Player.LOS[m.P] {
 				g.Print("Marevor makes some strange gestures.")
 			}
-			mons.TeleportAway(g)
+			mons.TeleportAway(g, ev)
 			return true
 		}
 	}
@@ -1874,8 +1933,9 @@ func (m *monster) AttackAction(g *game, ev event) {
 		m.Obstructing = false
 		p := m.AlternatePlacement(g)
 		if p != nil {
-			m.MoveTo(g, *p)
-			ev.Renew(g, m.Kind.MovementDelay())
+			if m.MoveTo(g, *p, ev) || m.Exists() {
+				ev.Renew(g, m.MovementDelay())
+			}
 			return
 		}
 		fallthrough
@@ -1889,11 +1949,7 @@ func (m *monster) AttackAction(g *game, ev event) {
 		} else {
 			m.HitPlayer(g, ev)
 		}
-		adelay := m.Kind.AttackDelay()
-		if m.Status(MonsSlow) {
-			adelay += 3
-		}
-		ev.Renew(g, adelay)
+		ev.Renew(g, m.AttackDelay())
 	}
 }
 
@@ -1904,6 +1960,10 @@ func (m *monster) NaturalAwake(g *game) {
 }
 
 func (m *monster) HandleTurn(g *game, ev event) {
+	if m.Ally {
+		m.HandleAllyTurn(g, ev)
+		return
+	}
 	ppos := g.Player.P
 	mpos := m.P
 	m.MakeAware(g)
@@ -1914,16 +1974,34 @@ func (m *monster) HandleTurn(g *game, ev event) {
 			m.State = Wandering
 		}
 	}
-	movedelay := m.Kind.MovementDelay()
-	if m.Status(MonsSlow) {
-		movedelay += 3
+	if m.State == Hunting || m.State == Wandering {
+		m.FollowScent(g)
 	}
+	movedelay := m.MovementDelay()
 	if m.State == Resting {
 		wander := RandInt(100 + 6*Max(800-(g.DepthPlayerTurn+1), 0))
 		if wander == 0 {
 			m.NaturalAwake(g)
 		}
-		ev.Renew(g, m.Kind.MovementDelay())
+		ev.Renew(g, m.MovementDelay())
+		return
+	}
+	if m.State == Hunting && !m.Status(StatusEnraged) && m.Frightened(g) {
+		m.State = Fleeing
+		m.Path = nil
+		if g.Player.LOS[m.P] {
+			g.Printf("%s flees!", m.Kind.Definite(true))
+		}
+	}
+	if m.State == Fleeing {
+		m.Flee(g, ev, movedelay)
+		return
+	}
+	if m.Infight(g, ev) {
+		return
+	}
+	tactics := m.Tactics(g)
+	if m.State == Hunting && tactics&TacticRangedBehind != 0 && m.Kind.Ranged() && m.KeepDistance(g, ev, movedelay) {
 		return
 	}
 	if m.State == Hunting && m.RangedAttack(g, ev) {
@@ -1944,9 +2022,16 @@ func (m *monster) HandleTurn(g *game, ev event) {
 			return
 		}
 	}
+	if m.State == Hunting && Distance(mpos, ppos) > 1 && !m.Status(StatusLignification) {
+		if ally := m.AdjacentAlly(g); ally != nil {
+			m.HitMonster(g, ally, ev)
+			ev.Renew(g, m.AttackDelay())
+			return
+		}
+	}
 	if Distance(mpos, ppos) == 1 {
 		attack := true
-		if m.Status(MonsConfused) {
+		if m.Status(StatusConfusion) {
 			switch Dir(m.P, g.Player.P) {
 			case E, N, W, S:
 			default:
@@ -1971,35 +2056,47 @@ func (m *monster) HandleTurn(g *game, ev event) {
 			return
 		}
 	}
-	if m.Status(MonsLignified) {
+	if m.Status(StatusLignification) {
 		ev.Renew(g, 10) // wait
 		return
 	}
 	if m.Kind == MonsMarevorHelith {
-		if m.TeleportMonsterAway(g) {
+		if m.TeleportMonsterAway(g, ev) {
 			ev.Renew(g, movedelay)
 			return
 		}
 	}
 	m.Obstructing = false
-	if !(len(m.Path) > 0 && m.Path[0] == mpos && m.Path[len(m.Path)-1] == m.Target) {
+	if m.State == Wandering && m.Behaviour == BehaveGuard && m.Target == m.Post && Distance(m.P, m.Post) <= 2 {
+		// close enough to watch over the post
+		m.Target = m.P
+	}
+	if m.State == Hunting && tactics&TacticSurround != 0 {
+		m.Surround(g)
+	}
+	if m.FlowPathing(g) {
+		m.Path = m.FlowPath(g, mpos, m.Target)
+	} else if !(len(m.Path) > 0 && m.Path[0] == mpos && m.Path[len(m.Path)-1] == m.Target) {
 		m.Path = m.APath(g, mpos, m.Target)
-		if len(m.Path) == 0 && !m.Status(MonsConfused) {
-			// if target is not accessible, try free neighbor cells
-			for _, npos := range g.Dungeon.FreeNeighbors(m.Target) {
-				m.Path = m.APath(g, mpos, npos)
-				if len(m.Path) > 0 {
-					m.Target = npos
-					break
-				}
+	}
+	if len(m.Path) == 0 && !m.Status(StatusConfusion) {
+		// if target is not accessible, try free neighbor cells
+		for _, npos := range g.Dungeon.FreeNeighbors(m.Target) {
+			m.Path = m.APath(g, mpos, npos)
+			if len(m.Path) > 0 {
+				m.Target = npos
+				break
 			}
 		}
 	}
 	if len(m.Path) < 2 {
 		switch m.State {
 		case Wandering:
+			if m.NextWanderTarget(g) {
+				break
+			}
 			keepWandering := RandInt(100)
-			if keepWandering > 75 && g.BandData[g.Bands[m.Band]].Band {
+			if keepWandering > 75 && m.BandInfo(g).Band {
 				for _, mons := range g.Monsters {
 					m.Target = mons.P
 				}
@@ -2008,6 +2105,9 @@ func (m *monster) HandleTurn(g *game, ev event) {
 			}
 			m.GatherBand(g)
 		case Hunting:
+			if m.ReturnToPost(g) {
+				break
+			}
 			// pick a random cell: more escape strategies for the player
 			if m.Kind == MonsHound && Distance(m.P, g.Player.P) <= 6 &&
 				!(g.Player.Aptitudes[AptStealthyMovement] && RandInt(2) == 0) {
@@ -2021,34 +2121,56 @@ func (m *monster) HandleTurn(g *game, ev event) {
 		ev.Renew(g, movedelay)
 		return
 	}
+	if m.State == Hunting && tactics&TacticAmbush != 0 && m.Ambush(g) {
+		ev.Renew(g, movedelay)
+		return
+	}
 	target := m.Path[1]
 	mons := g.MonsterAt(target)
 	switch {
 	case !mons.Exists():
 		if m.Kind == MonsEarthDragon && g.Dungeon.Cell(target).T == WallCell {
 			g.Dungeon.SetCell(target, FreeCell)
+			g.InvalidatePathMaps()
 			g.Stats.Digs++
 			if !g.Player.LOS[target] {
 				g.WrongWall[m.P] = true
 			}
-			g.MakeNoise(WallNoise, m.P)
+			g.MakeNoise(DigNoise, m.P)
 			g.Fog(m.P, 1, ev)
 			if Distance(g.Player.P, target) < 12 {
 				// XXX use dijkstra distance ?
 				g.Printf("%s You hear an earth-splitting noise.", g.CrackSound())
 				g.StopAuto()
 			}
-			m.MoveTo(g, target)
 			m.Path = m.Path[1:]
-		} else if g.Dungeon.Cell(target).T == WallCell {
+			if !m.MoveTo(g, target, ev) {
+				if m.Exists() {
+					ev.Renew(g, movedelay)
+				}
+				return
+			}
+		} else if g.Dungeon.Cell(target).T == WallCell || m.DoorBlocks(g, target) {
 			m.Path = m.APath(g, mpos, m.Target)
 		} else {
 			m.InvertFoliage(g)
-			m.MoveTo(g, target)
+			// advance the path first: a trap may reset it
+			m.Path = m.Path[1:]
+			if !m.MoveTo(g, target, ev) {
+				if m.Exists() {
+					ev.Renew(g, movedelay)
+				}
+				return
+			}
 			if (m.Kind.Ranged() || m.Kind.Smiting()) && !m.FireReady && g.Player.LOS[m.P] {
 				m.FireReady = true
 			}
-			m.Path = m.Path[1:]
+		}
+	case mons.Ally || m.Status(StatusEnraged):
+		if m.State == Hunting {
+			m.HitMonster(g, mons, ev)
+		} else {
+			m.Path = m.APath(g, mpos, m.Target)
 		}
 	case m.State == Hunting && mons.State != Hunting:
 		r := RandInt(5)
@@ -2118,11 +2240,14 @@ func (m *monster) DramaticAdjustment(g *game, baseAttack, attack, evasion, acc i
 	if attack >= g.Player.HP {
 		// a little dramatic effect
 		if RandInt(2) == 0 {
+			oattack := attack
 			attack, clang = g.HitDamage(DmgPhysical, baseAttack, g.Player.Armor())
+			g.CombatPrintf("dramatic adjustment: damage rerolled %d → %d", oattack, attack)
 		}
 		if attack >= g.Player.HP {
 			n := RandInt(g.Player.Evasion())
 			if n > evasion {
+				g.CombatPrintf("dramatic adjustment: evasion rerolled %d → %d", evasion, n)
 				evasion = n
 			}
 		}
@@ -2138,8 +2263,7 @@ func (m *monster) Exhaust(g *game) {
 }
 
 func (m *monster) ExhaustTime(g *game, t int) {
-	m.Statuses[MonsExhausted]++
-	g.PushEvent(&monsterEvent{ERank: g.Ev.Rank() + t, NMons: m.Index, EAction: MonsExhaustionEnd})
+	m.PutStatusFor(g, StatusExhausted, t, g.Ev)
 }
 
 func (m *monster) HitPlayer(g *game, ev event) {
@@ -2148,8 +2272,10 @@ func (m *monster) HitPlayer(g *game, ev event) {
 	}
 	evasion := RandInt(g.Player.Evasion())
 	acc := RandInt(m.Accuracy)
-	attack, clang := g.HitDamage(DmgPhysical, m.Attack, g.Player.Armor())
+	dr := g.DamageRoll(DmgPhysical, m.Attack, g.Player.Armor())
+	attack, clang := dr.Damage(), dr.Clang
 	attack, evasion, clang = m.DramaticAdjustment(g, m.Attack, attack, evasion, acc, clang)
+	m.CombatLogAttack(g, "attacks", acc, evasion, dr, attack, acc > evasion)
 	if acc > evasion {
 		if m.Blocked(g) {
 			g.Printf("Clang! You block %s's attack.", m.Kind.Definite(false))
@@ -2157,8 +2283,8 @@ func (m *monster) HitPlayer(g *game, ev event) {
 			g.BlockEffects(m)
 			return
 		}
-		if g.Player.HasStatus(StatusSwap) && !g.Player.HasStatus(StatusLignification) && !m.Status(MonsLignified) {
-			g.SwapWithMonster(m)
+		if g.Player.HasStatus(StatusSwap) && !g.Player.HasStatus(StatusLignification) && !m.Status(StatusLignification) {
+			g.SwapWithMonster(m, ev)
 			return
 		}
 		noise := g.HitNoise(clang)
@@ -2185,15 +2311,18 @@ func (m *monster) HitPlayer(g *game, ev event) {
 		m.HitSideEffects(g, ev)
 		const HeavyWoundHP = 18
 		if g.Player.Aptitudes[AptConfusingGas] && g.Player.HP < HeavyWoundHP && RandInt(2) == 0 {
-			m.EnterConfusion(g, ev)
+			g.CombatPrintf("aptitude triggered: %s", AptConfusingGas)
 			g.Printf("You release some confusing gas against the %s.", m.Kind)
+			g.ReleaseGas(m.P, CloudConfusion, ev)
 		}
 		if g.Player.Aptitudes[AptSmoke] && g.Player.HP < HeavyWoundHP && RandInt(2) == 0 {
+			g.CombatPrintf("aptitude triggered: %s", AptSmoke)
 			g.Smoke(ev)
 		}
 		if g.Player.Aptitudes[AptObstruction] && g.Player.HP <= HeavyWoundHP && RandInt(2) == 0 {
+			g.CombatPrintf("aptitude triggered: %s", AptObstruction)
 			opos := m.P
-			m.Blink(g)
+			m.Blink(g, ev)
 			if opos != m.P {
 				g.TemporalWallAt(opos, ev)
 				g.Print("A temporal wall emerges.")
@@ -2201,9 +2330,11 @@ func (m *monster) HitPlayer(g *game, ev event) {
 			}
 		}
 		if g.Player.Aptitudes[AptTeleport] && g.Player.HP < HeavyWoundHP && RandInt(2) == 0 {
-			m.TeleportAway(g)
+			g.CombatPrintf("aptitude triggered: %s", AptTeleport)
+			m.TeleportAway(g, ev)
 		}
 		if g.Player.Aptitudes[AptLignification] && g.Player.HP < HeavyWoundHP && RandInt(2) == 0 {
+			g.CombatPrintf("aptitude triggered: %s", AptLignification)
 			m.EnterLignification(g, ev)
 		}
 	} else {
@@ -2213,23 +2344,12 @@ func (m *monster) HitPlayer(g *game, ev event) {
 }
 
 func (m *monster) EnterConfusion(g *game, ev event) {
-	if !m.Status(MonsConfused) {
-		m.Statuses[MonsConfused] = 1
-		m.Path = m.Path[:0]
-		g.PushEvent(&monsterEvent{
-			ERank: ev.Rank() + 50 + RandInt(100), NMons: m.Index, EAction: MonsConfusionEnd})
-	}
+	m.PutStatusFor(g, StatusConfusion, 50+RandInt(100), ev)
 }
 
 func (m *monster) EnterLignification(g *game, ev event) {
-	if !m.Status(MonsLignified) {
-		m.Statuses[MonsLignified] = 1
-		m.Path = m.Path[:0]
-		g.PushEvent(&monsterEvent{
-			ERank: ev.Rank() + 150 + RandInt(100), NMons: m.Index, EAction: MonsLignificationEnd})
-		if g.Player.LOS[m.P] {
-			g.Printf("%s is rooted to the ground.", m.Kind.Definite(true))
-		}
+	if m.PutStatus(g, StatusLignification, ev) && g.Player.LOS[m.P] {
+		g.Printf("%s is rooted to the ground.", m.Kind.Definite(true))
 	}
 }
 
@@ -2239,13 +2359,18 @@ func (m *monster) HitSideEffects(g *game, ev event) {
 		if RandInt(2) == 0 {
 			g.Confusion(ev)
 		}
+		if RandInt(3) == 0 {
+			g.Poison(ev)
+		}
+	case MonsCaveViper:
+		g.Poison(ev)
+	case MonsSkeletonWarrior:
+		if RandInt(3) == 0 {
+			g.Bleed(ev)
+		}
 	case MonsGiantBee:
 		if RandInt(5) == 0 && !g.Player.HasStatus(StatusBerserk) && !g.Player.HasStatus(StatusExhausted) {
-			g.Player.Statuses[StatusBerserk] = 1
-			g.Player.HP += 10
-			end := ev.Rank() + 25 + RandInt(30)
-			g.PushEvent(&simpleEvent{ERank: end, EAction: BerserkEnd})
-			g.Player.Expire[StatusBerserk] = end
+			g.PutStatusFor(StatusBerserk, 25+RandInt(30), ev)
 			g.Print("You feel a sudden urge to kill things.")
 		}
 	case MonsBlinkingFrog:
@@ -2259,11 +2384,11 @@ func (m *monster) HitSideEffects(g *game, ev event) {
 			g.Print("The yack pushes you.")
 		}
 	case MonsWingedMilfid:
-		if m.Status(MonsExhausted) || g.Player.HasStatus(StatusLignification) {
+		if m.Status(StatusExhausted) || g.Player.HasStatus(StatusLignification) {
 			break
 		}
 		ompos := m.P
-		m.MoveTo(g, g.Player.P)
+		m.MoveTo(g, g.Player.P, ev)
 		g.PlacePlayerAt(ompos)
 		g.Print("The flying milfid makes you swap positions.")
 		m.ExhaustTime(g, 50+RandInt(50))
@@ -2298,13 +2423,13 @@ func (m *monster) RangedAttack(g *game, ev event) bool {
 	if !m.FireReady {
 		m.FireReady = true
 		if Distance(m.P, g.Player.P) <= 3 {
-			ev.Renew(g, m.Kind.AttackDelay())
+			ev.Renew(g, m.AttackDelay())
 			return true
 		} else {
 			return false
 		}
 	}
-	if m.Status(MonsExhausted) {
+	if m.Status(StatusExhausted) {
 		return false
 	}
 	switch m.Kind {
@@ -2359,25 +2484,32 @@ func (m *monster) TormentBolt(g *game, ev event) bool {
 		g.ui.MonsterProjectileAnimation(g.Ray(m.P), '*', ColorCyan)
 	}
 	m.Exhaust(g)
-	ev.Renew(g, m.Kind.AttackDelay())
+	ev.Renew(g, m.AttackDelay())
 	return true
 }
 
+// CanBlock reports whether the player can block an attack with a shield.
+func (g *game) CanBlock() bool {
+	return g.Player.Shield != NoShield && !g.Player.Weapon.TwoHanded() && !g.Player.Blocked
+}
+
 func (m *monster) Blocked(g *game) bool {
 	blocked := false
-	if g.Player.Shield != NoShield && !g.Player.Weapon.TwoHanded() && !g.Player.Blocked {
+	if g.CanBlock() {
 		block := RandInt(g.Player.Block())
 		acc := RandInt(m.Accuracy)
 		if block >= acc {
 			blocked = true
+			g.CombatPrintf("block %d (max %d) vs accuracy %d (max %d): blocked", block, g.Player.Block(), acc, m.Accuracy)
+		} else {
+			g.CombatPrintf("block %d (max %d) vs accuracy %d (max %d): not blocked", block, g.Player.Block(), acc, m.Accuracy)
 		}
 	}
 	return blocked
 }
 
 func (m *monster) ThrowRock(g *game, ev event) bool {
-	blocked := m.RangeBlocked(g)
-	if blocked {
+	if m.ProjectileBlocked(g) {
 		return false
 	}
 	block := false
@@ -2385,8 +2517,13 @@ func (m *monster) ThrowRock(g *game, ev event) bool {
 	evasion := RandInt(g.Player.Evasion())
 	acc := RandInt(m.Accuracy)
 	const rockdmg = 15
-	attack, clang := g.HitDamage(DmgPhysical, rockdmg, g.Player.Armor())
+	if mons := m.RangeTarget(g); mons != nil {
+		return m.ThrowAtMonster(g, mons, "a rock", rockdmg, ev)
+	}
+	dr := g.DamageRoll(DmgPhysical, rockdmg, g.Player.Armor())
+	attack, clang := dr.Damage(), dr.Clang
 	attack, evasion, clang = m.DramaticAdjustment(g, rockdmg, attack, evasion, acc, clang)
+	m.CombatLogAttack(g, "throws a rock", acc, evasion, dr, attack, 4*acc/3 > evasion)
 	if 4*acc/3 <= evasion {
 		// rocks are big and do not miss so often
 		hit = false
@@ -2433,9 +2570,9 @@ func (m *monster) ThrowRock(g *game, ev event) bool {
 			if mons.Exists() {
 				mons.HP -= RandInt(15)
 				if mons.HP <= 0 {
-					g.HandleKill(mons, ev)
+					g.HandleKill(mons, m.KillSource(), ev)
 				} else {
-					mons.Blink(g)
+					mons.Blink(g, ev)
 					if mons.P != p {
 						g.TemporalWallAt(p, ev)
 					}
@@ -2445,7 +2582,7 @@ func (m *monster) ThrowRock(g *game, ev event) bool {
 			}
 		}
 	}
-	ev.Renew(g, 2*m.Kind.AttackDelay())
+	ev.Renew(g, 2*m.AttackDelay())
 	return true
 }
 
@@ -2454,11 +2591,10 @@ func (m *monster) VampireSpit(g *game, ev event) bool {
 	if blocked || g.Player.HasStatus(StatusNausea) {
 		return false
 	}
-	g.Player.Statuses[StatusNausea]++
-	g.PushEvent(&simpleEvent{ERank: ev.Rank() + 30 + RandInt(20), EAction: NauseaEnd})
+	g.PutStatus(StatusNausea, ev)
 	g.Print("The vampire spits at you. You feel sick.")
 	m.Exhaust(g)
-	ev.Renew(g, m.Kind.AttackDelay())
+	ev.Renew(g, m.AttackDelay())
 	return true
 }
 
@@ -2470,13 +2606,12 @@ func (m *monster) ThrowSpores(g *game, ev event) bool {
 	g.EnterLignification(ev)
 	g.Print("The tree mushroom releases spores. You feel rooted to the ground.")
 	m.Exhaust(g)
-	ev.Renew(g, m.Kind.AttackDelay())
+	ev.Renew(g, m.AttackDelay())
 	return true
 }
 
 func (m *monster) ThrowJavelin(g *game, ev event) bool {
-	blocked := m.RangeBlocked(g)
-	if blocked {
+	if m.ProjectileBlocked(g) {
 		return false
 	}
 	block := false
@@ -2484,8 +2619,13 @@ func (m *monster) ThrowJavelin(g *game, ev event) bool {
 	evasion := RandInt(g.Player.Evasion())
 	acc := RandInt(m.Accuracy)
 	const jdmg = 11
-	attack, clang := g.HitDamage(DmgPhysical, jdmg, g.Player.Armor())
+	if mons := m.RangeTarget(g); mons != nil {
+		return m.ThrowAtMonster(g, mons, "a javelin", jdmg, ev)
+	}
+	dr := g.DamageRoll(DmgPhysical, jdmg, g.Player.Armor())
+	attack, clang := dr.Damage(), dr.Clang
 	attack, evasion, clang = m.DramaticAdjustment(g, jdmg, attack, evasion, acc, clang)
+	m.CombatLogAttack(g, "throws a javelin", acc, evasion, dr, attack, acc > evasion)
 	if acc <= evasion {
 		hit = false
 	} else {
@@ -2509,8 +2649,7 @@ func (m *monster) ThrowJavelin(g *game, ev event) bool {
 			g.BlockEffects(m)
 			g.ui.MonsterJavelinAnimation(g.Ray(m.P), false)
 		} else if !g.Player.HasStatus(StatusDisabledShield) {
-			g.Player.Statuses[StatusDisabledShield] = 1
-			g.PushEvent(&simpleEvent{ERank: ev.Rank() + 100 + RandInt(100), EAction: DisabledShieldEnd})
+			g.PutStatus(StatusDisabledShield, ev)
 			g.Printf("%s's %s gets embedded in your shield.", m.Kind.Indefinite(true), "javelin")
 			g.MakeNoise(ShieldBlockNoise, g.Player.P)
 			g.ui.MonsterJavelinAnimation(g.Ray(m.P), false)
@@ -2521,13 +2660,12 @@ func (m *monster) ThrowJavelin(g *game, ev event) bool {
 		g.ui.MonsterJavelinAnimation(g.Ray(m.P), false)
 	}
 	m.ExhaustTime(g, 50+RandInt(50))
-	ev.Renew(g, m.Kind.AttackDelay())
+	ev.Renew(g, m.AttackDelay())
 	return true
 }
 
 func (m *monster) ThrowAcid(g *game, ev event) bool {
-	blocked := m.RangeBlocked(g)
-	if blocked {
+	if m.ProjectileBlocked(g) {
 		return false
 	}
 	block := false
@@ -2535,8 +2673,13 @@ func (m *monster) ThrowAcid(g *game, ev event) bool {
 	evasion := RandInt(g.Player.Evasion())
 	acc := RandInt(m.Accuracy)
 	acdmg := 12
-	attack, clang := g.HitDamage(DmgPhysical, acdmg, g.Player.Armor())
+	if mons := m.RangeTarget(g); mons != nil {
+		return m.ThrowAtMonster(g, mons, "acid", acdmg, ev)
+	}
+	dr := g.DamageRoll(DmgPhysical, acdmg, g.Player.Armor())
+	attack, clang := dr.Damage(), dr.Clang
 	attack, evasion, _ = m.DramaticAdjustment(g, acdmg, attack, evasion, acc, clang)
+	m.CombatLogAttack(g, "throws acid", acc, evasion, dr, attack, acc > evasion)
 	if acc <= evasion {
 		hit = false
 	} else {
@@ -2567,7 +2710,7 @@ func (m *monster) ThrowAcid(g *game, ev event) bool {
 		g.Printf("You dodge %s's acid projectile.", m.Kind.Indefinite(false))
 		g.ui.MonsterProjectileAnimation(g.Ray(m.P), '*', ColorGreen)
 	}
-	ev.Renew(g, m.Kind.AttackDelay())
+	ev.Renew(g, m.AttackDelay())
 	return true
 }
 
@@ -2586,7 +2729,7 @@ func (m *monster) NixeAttraction(g *game, ev event) bool {
 		g.PlacePlayerAt(ray[1])
 	}
 	m.Exhaust(g)
-	ev.Renew(g, m.Kind.AttackDelay())
+	ev.Renew(g, m.AttackDelay())
 	return true
 }
 
@@ -2601,13 +2744,13 @@ func (m *monster) SmitingAttack(g *game, ev event) bool {
 	if !m.FireReady {
 		m.FireReady = true
 		if Distance(m.P, g.Player.P) <= 3 {
-			ev.Renew(g, m.Kind.AttackDelay())
+			ev.Renew(g, m.AttackDelay())
 			return true
 		} else {
 			return false
 		}
 	}
-	if m.Status(MonsExhausted) {
+	if m.Status(StatusExhausted) {
 		return false
 	}
 	switch m.Kind {
@@ -2626,7 +2769,7 @@ func (m *monster) AbsorbMana(g *game, ev event) bool {
 	g.Player.MP -= 1
 	g.Printf("%s absorbs your mana.", m.Kind.Definite(true))
 	m.ExhaustTime(g, 10+RandInt(10))
-	ev.Renew(g, m.Kind.AttackDelay())
+	ev.Renew(g, m.AttackDelay())
 	return true
 }
 
@@ -2644,19 +2787,18 @@ func (m *monster) MindAttack(g *game, ev event) bool {
 	g.Printf("The celmist mage hurts your mind (%d dmg).", dmg)
 	if RandInt(2) == 0 {
 		if RandInt(2) == 0 {
-			g.Player.Statuses[StatusSlow]++
-			g.PushEvent(&simpleEvent{ERank: ev.Rank() + 30 + RandInt(10), EAction: SlowEnd})
+			g.PutStatus(StatusSlow, ev)
 		} else {
 			g.Confusion(ev)
 		}
 	}
-	ev.Renew(g, m.Kind.AttackDelay())
+	ev.Renew(g, m.AttackDelay())
 	return true
 }
 
 func (m *monster) Explode(g *game, ev event) {
 	neighbors := ValidNeighbors(m.P)
-	g.MakeNoise(WallNoise, m.P)
+	g.MakeNoise(ExplosionNoise, m.P)
 	g.Printf("%s %s explodes with a loud boom.", g.ExplosionSound(), m.Kind.Definite(true))
 	g.ui.ExplosionAnimation(FireExplosion, m.P)
 	for _, p := range append(neighbors, m.P) {
@@ -2678,6 +2820,7 @@ func (m *monster) Explode(g *game, ev event) {
 			m.InflictDamage(g, dmg, 15)
 		} else if c.T == WallCell && RandInt(2) == 0 {
 			g.Dungeon.SetCell(p, FreeCell)
+			g.InvalidatePathMaps()
 			g.Stats.Digs++
 			if !g.Player.LOS[p] {
 				g.WrongWall[p] = true
@@ -2690,7 +2833,7 @@ func (m *monster) Explode(g *game, ev event) {
 	}
 }
 
-func (m *monster) Blink(g *game) {
+func (m *monster) Blink(g *game, ev event) {
 	npos := g.BlinkPos()
 	if !valid(npos) || npos == g.Player.P || npos == m.P {
 		return
@@ -2698,7 +2841,7 @@ func (m *monster) Blink(g *game) {
 	opos := m.P
 	g.Printf("The %s blinks away.", m.Kind)
 	g.ui.TeleportAnimation(opos, npos, true)
-	m.MoveTo(g, npos)
+	m.MoveTo(g, npos, ev)
 }
 
 func (m *monster) MakeHunt(g *game) {
@@ -2707,7 +2850,7 @@ func (m *monster) MakeHunt(g *game) {
 }
 
 func (m *monster) MakeHuntIfHurt(g *game) {
-	if m.Exists() && m.State != Hunting {
+	if m.Exists() && m.State != Hunting && m.State != Fleeing {
 		m.MakeHunt(g)
 		if m.State == Resting {
 			g.Printf("%s awakens.", m.Kind.Definite(true))
@@ -2732,11 +2875,18 @@ func (m *monster) MakeAwareIfHurt(g *game) {
 }
 
 func (m *monster) MakeAware(g *game) {
-	if !g.Player.LOS[m.P] {
+	if !g.Player.LOS[m.P] || m.State == Fleeing {
+		return
+	}
+	if m.Status(StatusShadows) && Distance(m.P, g.Player.P) > 1 {
+		// shadows cloud the monster's vision
+		return
+	}
+	if g.HiddenInDarkness(m) {
 		return
 	}
 	if m.State == Resting {
-		if m.Status(MonsExhausted) && (Distance(m.P, g.Player.P) > 1 || RandInt(3) > 0) {
+		if m.Status(StatusExhausted) && (Distance(m.P, g.Player.P) > 1 || RandInt(3) > 0) {
 			return
 		}
 		adjust := g.LosRange() - Distance(m.P, g.Player.P)
@@ -2807,8 +2957,135 @@ func (m *monster) Heal(g *game, ev event) {
 	ev.Renew(g, 50)
 }
 
+// Fearless reports whether monsters of this kind never flee.
+func (mk monsterKind) Fearless() bool {
+	switch mk {
+	case MonsSatowalgaPlant, MonsTreeMushroom, MonsSkeletonWarrior, MonsLich, MonsExplosiveNadre,
+		MonsAcidMound, MonsMirrorSpecter, MonsEarthDragon, MonsMarevorHelith, MonsWorm:
+		return true
+	default:
+		return false
+	}
+}
+
+// Cowardly reports whether monsters of this kind flee more easily.
+func (mk monsterKind) Cowardly() bool {
+	switch mk {
+	case MonsGoblin, MonsTinyHarpy, MonsBlinkingFrog, MonsBrizzia, MonsYack:
+		return true
+	default:
+		return false
+	}
+}
+
+// BandLosses returns the number of dead monsters in the monster's band, and
+// the band's initial size.
+func (m *monster) BandLosses(g *game) (lost, total int) {
+	for _, mons := range g.Monsters {
+		if mons.Band != m.Band {
+			continue
+		}
+		total++
+		if !mons.Exists() {
+			lost++
+		}
+	}
+	return lost, total
+}
+
+// Frightened reports whether a monster's morale is low enough to flee. It
+// depends on the monster's health, its kind, band losses and nearby allies.
+func (m *monster) Frightened(g *game) bool {
+	if m.Kind.Fearless() || m.Status(StatusLignification) {
+		return false
+	}
+	threshold := 20 // HP percent under which the monster flees
+	if m.Kind.Cowardly() {
+		threshold += 15
+	}
+	lost, total := m.BandLosses(g)
+	if total > 1 && 2*lost >= total {
+		threshold += 20
+	}
+	for _, mons := range g.Monsters {
+		if mons != m && mons.Exists() && mons.State == Hunting && Distance(mons.P, m.P) <= 2 {
+			// allies nearby give courage
+			threshold -= 5
+		}
+	}
+	return m.HP*100 < threshold*m.HPmax
+}
+
+// FleeDistance is the distance at which fleeing monsters feel safe.
+const FleeDistance = 12
+
+// FleeStep returns the neighbor position that leads farther from the
+// player, along with its distance from the player.
+func (m *monster) FleeStep(g *game) (gruid.Point, int) {
+	g.PR.BreadthFirstMap(&noisePath{game: g}, []gruid.Point{g.Player.P}, FleeDistance)
+	best := m.P
+	bestc := g.PR.BreadthFirstMapAt(m.P)
+	for _, p := range g.Dungeon.FreeNeighbors(m.P) {
+		if p == g.Player.P || g.MonsterAt(p).Exists() || m.DoorBlocks(g, p) {
+			continue
+		}
+		c := g.PR.BreadthFirstMapAt(p)
+		if c > bestc {
+			best = p
+			bestc = c
+		}
+	}
+	return best, bestc
+}
+
+// Flee handles the turn of a fleeing monster. It moves away from the player,
+// and fights back only when cornered. Once out of reach, it regroups with its
+// band.
+func (m *monster) Flee(g *game, ev event, movedelay int) {
+	p, c := m.FleeStep(g)
+	if p == m.P || m.Status(StatusLignification) {
+		if Distance(m.P, g.Player.P) == 1 {
+			// cornered
+			m.AttackAction(g, ev)
+			return
+		}
+		if !g.Player.LOS[m.P] {
+			m.Regroup(g)
+		}
+		ev.Renew(g, movedelay)
+		return
+	}
+	if !m.MoveTo(g, p, ev) {
+		if m.Exists() {
+			ev.Renew(g, movedelay)
+		}
+		return
+	}
+	if c >= FleeDistance && !g.Player.LOS[m.P] {
+		m.Regroup(g)
+	}
+	ev.Renew(g, movedelay)
+}
+
+// Regroup makes a monster stop fleeing and gather its band.
+func (m *monster) Regroup(g *game) {
+	m.State = Wandering
+	m.Target = m.P
+	m.Path = nil
+	m.GatherBand(g)
+}
+
+// BandInfo returns the data of the monster's band. Allies do not belong to
+// any band.
+func (m *monster) BandInfo(g *game) monsterBandData {
+	if m.Band == AllyBand {
+		return monsterBandData{}
+	}
+	return g.BandData[g.Bands[m.Band]]
+}
+
 func (m *monster) GatherBand(g *game) {
-	if !g.BandData[g.Bands[m.Band]].Band {
+	if !m.BandInfo(g).Band {
 		return
 	}
 	dij := &normalPath{game: g}
@@ -2820,7 +3097,7 @@ func (m *monster) GatherBand(g *game) {
 				continue
 			}
 			c := g.PR.BreadthFirstMapAt(mons.P)
-			if c > radius || mons.State == Resting && mons.Status(MonsExhausted) && RandInt(2) == 0 {
+			if c > radius || mons.State == Resting && mons.Status(StatusExhausted) && RandInt(2) == 0 {
 				continue
 			}
 			r := RandInt(100)
@@ -2867,7 +3144,34 @@ func (g *game) MaxDanger() int {
 		10: 245,
 		11: 285,
 	}
-	max := danger[g.Depth]
+	var max int
+	depth := g.DangerDepth()
+	if depth > MaxDepth {
+		// endless mode: extrapolate from the last levels
+		max = danger[MaxDepth] + (danger[MaxDepth]-danger[MaxDepth-1])*(depth-MaxDepth)
+	} else {
+		max = danger[depth]
+	}
+	if g.Opts.Daily == "" {
+		// daily runs face the same monsters whatever the player's items
+		max = g.PlayerAdjustedDanger(max)
+	}
+	switch g.Dungeon.Gen {
+	case GenCaveMapTree:
+		max = max * 90 / 100
+	case GenCaveMap:
+		max = max * 95 / 100
+	case GenRoomMap:
+		max = max * 105 / 100
+	case GenRuinsMap:
+		max = max * 108 / 100
+	case GenBSPMap:
+		max = max * 115 / 100
+	}
+	return max
+}
+
+func (g *game) PlayerAdjustedDanger(max int) int {
 	adjust := -2 * g.Depth
 	for c, q := range g.Player.Consumables {
 		switch c {
@@ -2875,7 +3179,7 @@ func (g *game) MaxDanger() int {
 			adjust += Min(5, g.Depth) * Min(q, Min(5, g.Depth))
 		case TeleportationPotion, DigPotion, WallPotion:
 			adjust += Min(3, g.Depth) * Min(q, 3)
-		case SwiftnessPotion, LignificationPotion, MagicPotion, BerserkPotion, ExplosiveMagara, ShadowsPotion, AccuracyPotion, TormentPotion, TeleportMagara, NightMagara:
+		case SwiftnessPotion, LignificationPotion, MagicPotion, BerserkPotion, ExplosiveMagara, ShadowsPotion, AccuracyPotion, TormentPotion, TeleportMagara, NightMagara, DiscordMagara:
 			adjust += Min(2, g.Depth) * Min(q, 3)
 		case ConfusingDart:
 			adjust += Min(1, g.Depth) * Min(q, 7)
@@ -2906,21 +3210,11 @@ func (g *game) MaxDanger() int {
 	if g.Player.Consumables[DreamPotion] > 0 && WinDepth-g.Depth < g.Player.Consumables[DreamPotion] {
 		max = max * 105 / 100
 	}
-	switch g.Dungeon.Gen {
-	case GenCaveMapTree:
-		max = max * 90 / 100
-	case GenCaveMap:
-		max = max * 95 / 100
-	case GenRoomMap:
-		max = max * 105 / 100
-	case GenRuinsMap:
-		max = max * 108 / 100
-	case GenBSPMap:
-		max = max * 115 / 100
-	}
 	return max
 }
 
+const MaxEndlessMonsters = 60
+
 func (g *game) MaxMonsters() int {
 	nmons := [MaxDepth + 1]int{
 		1:  13,
@@ -2935,7 +3229,18 @@ func (g *game) MaxMonsters() int {
 		10: 39,
 		11: 42,
 	}
-	max := nmons[g.Depth]
+	var max int
+	depth := g.DangerDepth()
+	if depth > MaxDepth {
+		// endless mode: extrapolate from the last levels, but keep
+		// some room in the map
+		max = nmons[MaxDepth] + (nmons[MaxDepth]-nmons[MaxDepth-1])*(depth-MaxDepth)
+		if max > MaxEndlessMonsters {
+			max = MaxEndlessMonsters
+		}
+	} else {
+		max = nmons[depth]
+	}
 	switch g.Dungeon.Gen {
 	case GenCaveMapTree, GenCaveMap:
 		max = max * 90 / 100
@@ -3000,7 +3305,7 @@ loop:
 
 func (g *game) MonsterInLOS() *monster {
 	for _, mons := range g.Monsters {
-		if mons.Exists() && g.Player.LOS[mons.P] {
+		if mons.Exists() && !mons.Ally && g.Player.LOS[mons.P] {
 			return mons
 		}
 	}
@@ -10,6 +10,10 @@ import (
 
 const unreachable = 9999
 
+// FirePathCost is the cost of moving into fire for monsters, that avoid it
+// when possible.
+const FirePathCost = 12
+
 func valid(p gruid.Point) bool {
 	return p.Y >= 0 && p.Y < DungeonHeight && p.X >= 0 && p.X < DungeonWidth
 }
@@ -50,6 +54,15 @@ func (pp *playerPath) Neighbors(p gruid.Point) []gruid.Point {
 		if cld, ok := pp.game.Clouds[np]; ok && cld == CloudFire && !(pp.game.WrongDoor[np] || pp.game.WrongFoliage[np]) {
 			return false
 		}
+		if cld, ok := pp.game.Clouds[np]; ok && cld.Gas() && np != pp.goal {
+			return false
+		}
+		if pp.game.LockedDoor(np) && (np != pp.goal || pp.game.Player.Keys == 0) {
+			return false
+		}
+		if pp.game.KnownTraps[np] && np != pp.goal {
+			return false
+		}
 		return valid(np) && ((d.Cell(np).T == FreeCell && !pp.game.WrongWall[np] || d.Cell(np).T == WallCell && pp.game.WrongWall[np]) || pp.game.Player.HasStatus(StatusDig)) &&
 			d.Cell(np).Explored
 	}
@@ -128,6 +141,15 @@ func (ap *autoexplorePath) Neighbors(p gruid.Point) []gruid.Point {
 			// XXX little info leak
 			return false
 		}
+		if cld, ok := ap.game.Clouds[np]; ok && cld.Gas() {
+			return false
+		}
+		if ap.game.LockedDoor(np) {
+			return false
+		}
+		if ap.game.KnownTraps[np] {
+			return false
+		}
 		return valid(np) && (d.Cell(np).T == FreeCell && !ap.game.WrongWall[np] || d.Cell(np).T == WallCell && ap.game.WrongWall[np]) &&
 			!ap.game.ExclusionsMap[np]
 	}
@@ -151,10 +173,10 @@ type monPath struct {
 func (mp *monPath) Neighbors(p gruid.Point) []gruid.Point {
 	d := mp.game.Dungeon
 	keep := func(np gruid.Point) bool {
-		return valid(np) && (d.Cell(np).T != WallCell || mp.wall)
+		return valid(np) && (d.Cell(np).T != WallCell || mp.wall) && !mp.monster.DoorBlocks(mp.game, np)
 	}
 	var nb []gruid.Point
-	if mp.monster.Status(MonsConfused) {
+	if mp.monster.Status(StatusConfusion) {
 		nb = mp.nbs.Cardinal(p, keep)
 	} else {
 		nb = mp.nbs.All(p, keep)
@@ -167,6 +189,9 @@ func (mp *monPath) Neighbors(p gruid.Point) []gruid.Point {
 
 func (mp *monPath) Cost(from, to gruid.Point) int {
 	g := mp.game
+	if cld, ok := g.Clouds[to]; ok && cld == CloudFire {
+		return FirePathCost
+	}
 	mons := g.MonsterAt(to)
 	if !mons.Exists() {
 		if mp.wall && g.Dungeon.Cell(to).T == WallCell && mp.monster.State != Hunting {
@@ -174,7 +199,7 @@ func (mp *monPath) Cost(from, to gruid.Point) int {
 		}
 		return 1
 	}
-	if mons.Status(MonsLignified) {
+	if mons.Status(StatusLignification) {
 		return 8
 	}
 	return 4
@@ -12,6 +12,7 @@ type player struct {
 	HP          int
 	MP          int
 	Simellas    int
+	Keys        int
 	Armour      armour
 	Weapon      weapon
 	Shield      shield
@@ -20,6 +21,7 @@ type player struct {
 	Aptitudes   map[aptitude]bool
 	Statuses    map[status]int
 	Expire      map[status]int
+	StatusEpoch [NumStatuses]int // incremented when a status is cured
 	P           gruid.Point
 	Target      gruid.Point
 	LOS         map[gruid.Point]bool
@@ -270,6 +272,9 @@ func (g *game) Rest(ev event) error {
 	if cld, ok := g.Clouds[g.Player.P]; ok && cld == CloudFire {
 		return errors.New("You cannot rest on flames.")
 	}
+	if g.Player.HasStatus(StatusBleeding) {
+		return errors.New("You cannot rest while bleeding.")
+	}
 	if !g.NeedsRegenRest() && !g.StatusRest() {
 		return errors.New("You do not need to rest.")
 	}
@@ -283,9 +288,11 @@ func (g *game) Rest(ev event) error {
 	return nil
 }
 
+// StatusRest reports whether the player has some status to wait for. Healable
+// statuses are cured by a true rest instead.
 func (g *game) StatusRest() bool {
-	for _, q := range g.Player.Statuses {
-		if q > 0 {
+	for st, q := range g.Player.Statuses {
+		if q > 0 && !StatusData[st].healable {
 			return true
 		}
 	}
@@ -293,7 +300,7 @@ func (g *game) StatusRest() bool {
 }
 
 func (g *game) NeedsRegenRest() bool {
-	return g.Player.HP < g.Player.HPMax() || g.Player.MP < g.Player.MPMax()
+	return g.Player.HP < g.Player.HPMax() || g.Player.MP < g.Player.MPMax() || g.Player.HasStatus(StatusPoison)
 }
 
 func (g *game) Equip(ev event) error {
@@ -344,12 +351,19 @@ func (g *game) CollectGround() {
 		} else {
 			g.Printf("You pick up %d simellas.", g.Simellas[p])
 		}
-		g.DijkstraMapRebuild = true
+		g.InvalidatePathMaps()
 		delete(g.Simellas, p)
 	}
+	if g.Keys[p] {
+		g.Player.Keys++
+		g.InvalidatePathMaps()
+		delete(g.Keys, p)
+		g.Printf("You take a key (%d).", g.Player.Keys)
+		g.StoryPrint("Found a key.")
+	}
 	if c, ok := g.Collectables[p]; ok {
 		g.Player.Consumables[c.Consumable] += c.Quantity
-		g.DijkstraMapRebuild = true
+		g.InvalidatePathMaps()
 		delete(g.Collectables, p)
 		if c.Quantity > 1 {
 			g.Printf("You take %d %s.", c.Quantity, c.Consumable.Plural())
@@ -361,7 +375,7 @@ func (g *game) CollectGround() {
 	}
 	if r, ok := g.Rods[p]; ok {
 		g.Player.Rods[r] = rodProps{Charge: r.MaxCharge() - 1}
-		g.DijkstraMapRebuild = true
+		g.InvalidatePathMaps()
 		delete(g.Rods, p)
 		g.Printf("You take a %s.", r)
 		g.StoryPrintf("Found and took a %s.", r)
@@ -372,6 +386,10 @@ func (g *game) CollectGround() {
 		g.Print("You are standing on a staircase.")
 	} else if stn, ok := g.MagicalStones[p]; ok {
 		g.Printf("You are standing on %s.", Indefinite(stn.String(), false))
+	} else if f, ok := g.Features[p]; ok {
+		g.Printf("You are standing at %s.", Indefinite(f.String(), false))
+	} else if t, ok := g.Traps[p]; ok && g.KnownTraps[p] {
+		g.Printf("You are standing on %s.", Indefinite(t.String(), false))
 	} else if g.Doors[p] {
 		g.Print("You stand at the door.")
 	}
@@ -394,42 +412,51 @@ func (g *game) MovePlayer(p gruid.Point, ev event) error {
 	}
 	delay := 10
 	mons := g.MonsterAt(p)
+	if mons.Exists() && mons.Ally {
+		if g.Player.HasStatus(StatusLignification) || mons.Status(StatusLignification) {
+			return errors.New("You cannot swap positions with your ally while lignified.")
+		}
+		g.SwapWithMonster(mons, ev)
+		g.Stats.Moves++
+		ev.Renew(g, g.ActionDelay(delay+g.MovementDelayModifier()))
+		return nil
+	}
 	if g.Player.Weapon == DefenderFlail && !mons.Exists() {
-		mons = g.AttractMonster(p)
+		mons = g.AttractMonster(p, ev)
 	}
 	if !mons.Exists() {
 		if g.Player.HasStatus(StatusLignification) {
 			return errors.New("You cannot move while lignified")
 		}
+		if g.Player.HasStatus(StatusNet) {
+			return errors.New("You cannot move while caught in a net.")
+		}
+		if g.LockedDoor(p) {
+			return g.OpenLockedDoor(p, ev)
+		}
 		if c.T == WallCell {
 			g.Dungeon.SetCell(p, FreeCell)
-			g.MakeNoise(WallNoise, p)
+			g.InvalidatePathMaps()
+			g.MakeNoise(DigNoise, p)
 			g.Print(g.CrackSound())
 			g.Fog(p, 1, ev)
 			g.Stats.Digs++
 		}
-		if g.Player.Aptitudes[AptFast] {
-			// only fast for movement
-			delay -= 2
-		}
-		switch g.Player.Armour {
-		case TurtlePlates:
-			delay += 3
-		case SpeedRobe:
-			delay -= 3
-		case SmokingScales:
+		delay += g.MovementDelayModifier()
+		if g.Player.Armour == SmokingScales {
 			_, ok := g.Clouds[g.Player.P]
 			if !ok {
 				g.Clouds[g.Player.P] = CloudFog
 				g.PushEvent(&cloudEvent{ERank: ev.Rank() + 15 + RandInt(10), EAction: CloudEnd, P: g.Player.P})
 			}
 		}
-		if g.Player.HasStatus(StatusSwift) {
-			// only fast for movement
-			delay -= 3
-		}
 		g.Stats.Moves++
 		g.PlacePlayerAt(p)
+		if noise := g.FootstepNoise(); noise > 0 {
+			g.MakeNoise(noise, p)
+		}
+		g.TriggerTrap(ev)
+		g.PullLever()
 		if !g.Autoexploring {
 			g.BoredomAction(ev, 1)
 		}
@@ -440,6 +467,31 @@ func (g *game) MovePlayer(p gruid.Point, ev event) error {
 		g.FunAction()
 		g.AttackMonster(mons, ev)
 	}
+	ev.Renew(g, g.ActionDelay(delay))
+	return nil
+}
+
+// MovementDelayModifier returns the modifier to the player's delay that
+// only applies to movement.
+func (g *game) MovementDelayModifier() (delay int) {
+	if g.Player.Aptitudes[AptFast] {
+		delay -= 2
+	}
+	switch g.Player.Armour {
+	case TurtlePlates:
+		delay += 3
+	case SpeedRobe:
+		delay -= 3
+	}
+	if g.Player.HasStatus(StatusSwift) {
+		delay -= 3
+	}
+	return delay
+}
+
+// ActionDelay applies to a base delay the modifiers that apply both to
+// movement and attacks.
+func (g *game) ActionDelay(delay int) int {
 	if g.Player.HasStatus(StatusBerserk) {
 		delay -= 3
 	}
@@ -449,8 +501,7 @@ func (g *game) MovePlayer(p gruid.Point, ev event) error {
 	if delay < 3 {
 		delay = 3
 	}
-	ev.Renew(g, delay)
-	return nil
+	return delay
 }
 
 func (g *game) HealPlayer(ev event) {
@@ -481,24 +532,28 @@ func (g *game) Smoke(ev event) {
 			g.PushEvent(&cloudEvent{ERank: ev.Rank() + 100 + RandInt(100), EAction: CloudEnd, P: p})
 		}
 	}
-	g.Player.Statuses[StatusSwift]++
-	end := ev.Rank() + 20 + RandInt(10)
-	g.PushEvent(&simpleEvent{ERank: end, EAction: HasteEnd})
-	g.Player.Expire[StatusSwift] = end
+	g.PutStatusFor(StatusSwift, 20+RandInt(10), ev)
 	g.ComputeLOS()
 	g.Print("You feel an energy burst and smoke comes out from you.")
 }
 
 func (g *game) Corrosion(ev event) {
-	g.Player.Statuses[StatusCorrosion]++
-	g.PushEvent(&simpleEvent{ERank: ev.Rank() + 80 + RandInt(40), EAction: CorrosionEnd})
+	g.PutStatus(StatusCorrosion, ev)
 	g.Print("Your equipment gets corroded.")
 }
 
+func (g *game) Poison(ev event) {
+	g.PutStatus(StatusPoison, ev)
+	g.Print("You feel poisoned.")
+}
+
+func (g *game) Bleed(ev event) {
+	g.PutStatus(StatusBleeding, ev)
+	g.Print("You start bleeding.")
+}
+
 func (g *game) Confusion(ev event) {
-	if !g.Player.HasStatus(StatusConfusion) {
-		g.Player.Statuses[StatusConfusion]++
-		g.PushEvent(&simpleEvent{ERank: ev.Rank() + 100 + RandInt(100), EAction: ConfusionEnd})
+	if g.PutStatus(StatusConfusion, ev) {
 		g.Print("You feel confused.")
 	}
 }
@@ -511,7 +566,5 @@ func (g *game) PlacePlayerAt(p gruid.Point) {
 }
 
 func (g *game) EnterLignification(ev event) {
-	g.Player.Statuses[StatusLignification]++
-	g.PushEvent(&simpleEvent{ERank: ev.Rank() + 150 + RandInt(100), EAction: LignificationEnd})
-	g.Player.HP += 10
+	g.PutStatus(StatusLignification, ev)
 }
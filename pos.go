@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"sort"
 
 	"codeberg.org/anaseto/gruid"
 	"codeberg.org/anaseto/gruid/paths"
@@ -184,6 +185,16 @@ func idx(p gruid.Point) int {
 	return p.Y*DungeonWidth + p.X
 }
 
+// sortedPoints returns the positions of a set in a stable order.
+func sortedPoints(m map[gruid.Point]bool) []gruid.Point {
+	ps := make([]gruid.Point, 0, len(m))
+	for p := range m {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool { return idx(ps[i]) < idx(ps[j]) })
+	return ps
+}
+
 func Laterals(p gruid.Point, dir direction) []gruid.Point {
 	switch dir {
 	case E, ENE, ESE:
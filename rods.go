@@ -22,9 +22,10 @@ const (
 	RodLignification
 	RodHope
 	RodSwapping
+	RodCharming
 )
 
-const NumRods = int(RodSwapping) + 1
+const NumRods = int(RodCharming) + 1
 
 func (r rod) Letter() rune {
 	return '/'
@@ -59,6 +60,8 @@ func (r rod) Name() string {
 		text = "last hope"
 	case RodSwapping:
 		text = "swapping"
+	case RodCharming:
+		text = "charming"
 	}
 	return text
 }
@@ -96,6 +99,8 @@ func (r rod) Desc() string {
 		text = "creates an energy channel against a targeted monster. The damage done is inversely proportional to your health. It can burn foliage and doors."
 	case RodSwapping:
 		text = "makes you swap positions with a targeted monster."
+	case RodCharming:
+		text = fmt.Sprintf("charms a targeted monster, which then fights by your side. You cannot have more than %d allies at once. Allies close to you follow you when you take stairs.", MaxAllies)
 	}
 	return fmt.Sprintf("The %s %s Rods sometimes regain charges as you go deeper. This rod can have up to %d charges.", r, text, r.MaxCharge())
 }
@@ -110,6 +115,8 @@ func (r rod) MaxCharge() (charges int) {
 		charges = 5
 	case RodDigging, RodShatter:
 		charges = 3
+	case RodCharming:
+		charges = 2
 	default:
 		charges = 4
 	}
@@ -176,6 +183,8 @@ func (r rod) Use(g *game, ev event) error {
 		err = g.EvokeRodHope(ev)
 	case RodSwapping:
 		err = g.EvokeRodSwapping(ev)
+	case RodCharming:
+		err = g.EvokeRodCharming(ev)
 	}
 
 	if err != nil {
@@ -251,7 +260,7 @@ func (g *game) EvokeRodTeleportOther(ev event) error {
 	}
 	mons := g.MonsterAt(g.Player.Target)
 	// mons not nil (check done in the targeter)
-	mons.TeleportAway(g)
+	mons.TeleportAway(g, ev)
 	return nil
 }
 
@@ -298,7 +307,7 @@ func (g *game) EvokeRodFireBolt(ev event) error {
 		mons.HP -= dmg
 		if mons.HP <= 0 {
 			g.Printf("%s is killed by the bolt.", mons.Kind.Indefinite(true))
-			g.HandleKill(mons, ev)
+			g.HandleKill(mons, KillByPlayer, ev)
 		}
 		g.MakeNoise(MagicHitNoise, mons.P)
 		g.HandleStone(mons)
@@ -330,7 +339,7 @@ func (g *game) EvokeRodFireball(ev event) error {
 		mons.HP -= dmg
 		if mons.HP <= 0 {
 			g.Printf("%s is killed by the fireball.", mons.Kind.Indefinite(true))
-			g.HandleKill(mons, ev)
+			g.HandleKill(mons, KillByPlayer, ev)
 		}
 		g.MakeNoise(MagicHitNoise, mons.P)
 		g.HandleStone(mons)
@@ -380,7 +389,7 @@ func (g *game) EvokeRodLightning(ev event) error {
 		mons.HP -= dmg
 		if mons.HP <= 0 {
 			g.Printf("%s is killed by lightning.", mons.Kind.Indefinite(true))
-			g.HandleKill(mons, ev)
+			g.HandleKill(mons, KillByPlayer, ev)
 		}
 		g.MakeNoise(MagicHitNoise, mons.P)
 		g.HandleStone(mons)
@@ -407,6 +416,9 @@ const (
 	CloudFog cloud = iota
 	CloudFire
 	CloudNight
+	CloudPoison
+	CloudConfusion
+	CloudSteam
 )
 
 func (g *game) EvokeRodFog(ev event) error {
@@ -436,6 +448,7 @@ func (g *game) EvokeRodDigging(ev event) error {
 	p := g.Player.Target
 	for i := 0; i < 3; i++ {
 		g.Dungeon.SetCell(p, FreeCell)
+		g.InvalidatePathMaps()
 		g.Stats.Digs++
 		g.MakeNoise(WallNoise, p)
 		g.Fog(p, 1, ev)
@@ -459,6 +472,7 @@ func (g *game) EvokeRodShatter(ev event) error {
 	}
 	neighbors := g.Dungeon.FreeNeighbors(g.Player.Target)
 	g.Dungeon.SetCell(g.Player.Target, FreeCell)
+	g.InvalidatePathMaps()
 	g.Stats.Digs++
 	g.ComputeLOS()
 	g.MakeMonstersAware()
@@ -480,7 +494,7 @@ func (g *game) EvokeRodShatter(ev event) error {
 		mons.HP -= dmg
 		if mons.HP <= 0 {
 			g.Printf("%s is killed by the explosion.", mons.Kind.Indefinite(true))
-			g.HandleKill(mons, ev)
+			g.HandleKill(mons, KillByPlayer, ev)
 		}
 		g.MakeNoise(ExplosionHitNoise, mons.P)
 		g.HandleStone(mons)
@@ -504,7 +518,7 @@ func (g *game) EvokeRodLignification(ev event) error {
 	}
 	mons := g.MonsterAt(g.Player.Target)
 	// mons not nil (check done in targeter)
-	if mons.Status(MonsLignified) {
+	if mons.Status(StatusLignification) {
 		return errors.New("You cannot target a lignified monster.")
 	}
 	mons.EnterLignification(g, ev)
@@ -524,6 +538,7 @@ func (g *game) TemporalWallAt(p gruid.Point, ev event) {
 
 func (g *game) CreateTemporalWallAt(p gruid.Point, ev event) {
 	g.Dungeon.SetCell(p, WallCell)
+	g.InvalidatePathMaps()
 	delete(g.Clouds, p)
 	g.TemporalWalls[p] = true
 	g.PushEvent(&cloudEvent{ERank: ev.Rank() + 200 + RandInt(50), P: p, EAction: ObstructionEnd})
@@ -556,7 +571,7 @@ func (g *game) EvokeRodHope(ev event) error {
 	g.Printf("An energy channel hits %s (%d dmg).", mons.Kind.Definite(false), dmg)
 	if mons.HP <= 0 {
 		g.Printf("%s dies.", mons.Kind.Indefinite(true))
-		g.HandleKill(mons, ev)
+		g.HandleKill(mons, KillByPlayer, ev)
 	}
 	return nil
 }
@@ -570,18 +585,18 @@ func (g *game) EvokeRodSwapping(ev event) error {
 	}
 	mons := g.MonsterAt(g.Player.Target)
 	// mons not nil (check done in the targeter)
-	if mons.Status(MonsLignified) {
+	if mons.Status(StatusLignification) {
 		return errors.New("You cannot target a lignified monster.")
 	}
-	g.SwapWithMonster(mons)
+	g.SwapWithMonster(mons, ev)
 	return nil
 }
 
-func (g *game) SwapWithMonster(mons *monster) {
+func (g *game) SwapWithMonster(mons *monster, ev event) {
 	ompos := mons.P
 	g.Printf("You swap positions with the %s.", mons.Kind)
 	g.ui.SwappingAnimation(mons.P, g.Player.P)
-	mons.MoveTo(g, g.Player.P)
+	mons.MoveTo(g, g.Player.P, ev)
 	g.PlacePlayerAt(ompos)
 	mons.MakeAware(g)
 }
@@ -0,0 +1,85 @@
+package main
+
+import "codeberg.org/anaseto/gruid"
+
+const (
+	ScentMax   = 100
+	ScentDecay = 4
+)
+
+// UpdateScent makes the player's scent decay and lays fresh scent at the
+// player's position. Fog and steam dissipate scent faster, and fire burns it
+// away. The dungeon has no water terrain, so steam is the only water that
+// affects scent.
+func (g *game) UpdateScent() {
+	if g.Scent == nil {
+		g.Scent = map[gruid.Point]int{}
+	}
+	for p, s := range g.Scent {
+		decay := ScentDecay
+		if cld, ok := g.Clouds[p]; ok {
+			switch cld {
+			case CloudFire:
+				decay = ScentMax
+			case CloudFog, CloudSteam:
+				decay *= 3
+			}
+		}
+		s -= decay
+		if s <= 0 {
+			delete(g.Scent, p)
+			continue
+		}
+		g.Scent[p] = s
+	}
+	s := ScentMax
+	if cld, ok := g.Clouds[g.Player.P]; ok {
+		switch cld {
+		case CloudFire:
+			return
+		case CloudFog, CloudSteam:
+			s /= 2
+		}
+	}
+	g.Scent[g.Player.P] = s
+}
+
+func (mk monsterKind) Smelling() bool {
+	switch mk {
+	case MonsHound, MonsVampire:
+		return true
+	default:
+		return false
+	}
+}
+
+// FollowScent makes a smelling monster that does not see the player move
+// toward fresher scent. It returns true if a trail was found.
+func (m *monster) FollowScent(g *game) bool {
+	if !m.Kind.Smelling() || g.Player.LOS[m.P] || m.Status(StatusConfusion) {
+		return false
+	}
+	best := g.Scent[m.P]
+	bestp := InvalidPos
+	for _, p := range g.Dungeon.FreeNeighbors(m.P) {
+		if s := g.Scent[p]; s > best {
+			best = s
+			bestp = p
+		}
+	}
+	if !valid(bestp) {
+		return false
+	}
+	if m.State == Wandering && best < ScentMax/2 {
+		// old trail
+		return false
+	}
+	m.State = Hunting
+	m.Target = bestp
+	return true
+}
+
+// ScentAt returns the scent strength at a position, as a digit from 0 to 9.
+func (g *game) ScentAt(p gruid.Point) int {
+	return g.Scent[p] * 10 / (ScentMax + 1)
+}
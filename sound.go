@@ -0,0 +1,114 @@
+package main
+
+import (
+	"codeberg.org/anaseto/gruid"
+	"codeberg.org/anaseto/gruid/paths"
+)
+
+// soundPath is used for sound propagation: walls, closed doors and foliage
+// attenuate sound.
+type soundPath struct {
+	game *game
+	nbs  paths.Neighbors
+}
+
+func (sp *soundPath) Neighbors(p gruid.Point) []gruid.Point {
+	return sp.nbs.All(p, valid)
+}
+
+const (
+	WallSoundCost    = 6
+	DoorSoundCost    = 3
+	FoliageSoundCost = 2
+)
+
+func (sp *soundPath) Cost(from, to gruid.Point) int {
+	g := sp.game
+	if g.Dungeon.Cell(to).T == WallCell {
+		return WallSoundCost
+	}
+	if g.ClosedDoor(to) && to != g.Player.P && !g.MonsterAt(to).Exists() {
+		// closed door
+		return DoorSoundCost
+	}
+	if _, ok := g.Fungus[to]; ok {
+		return FoliageSoundCost
+	}
+	return 1
+}
+
+// heardNoise is a noise heard by the player out of sight, at an estimated
+// position.
+type heardNoise struct {
+	Volume int
+	Turn   int
+}
+
+const LoudNoise = 10
+
+// HearNoise records a noise heard by the player with a given volume. The
+// source position is only estimated: the fainter the noise, the less precise.
+func (g *game) HearNoise(at gruid.Point, volume int) {
+	if g.HeardNoise == nil {
+		g.HeardNoise = map[gruid.Point]heardNoise{}
+	}
+	imprecision := 0
+	switch {
+	case volume < LoudNoise/2:
+		imprecision = 2
+	case volume < LoudNoise:
+		imprecision = 1
+	}
+	p := at
+	if imprecision > 0 {
+		q := at.Add(gruid.Point{RandInt(2*imprecision+1) - imprecision, RandInt(2*imprecision+1) - imprecision})
+		if valid(q) && g.Dungeon.Cell(q).T == FreeCell {
+			p = q
+		}
+	}
+	if hn, ok := g.HeardNoise[p]; ok && hn.Volume > volume {
+		volume = hn.Volume
+	}
+	g.HeardNoise[p] = heardNoise{Volume: volume, Turn: g.Turn}
+	g.StopAuto()
+}
+
+// ForgetHeardNoises removes the noises heard before the last player turn.
+func (g *game) ForgetHeardNoises() {
+	for p, hn := range g.HeardNoise {
+		if hn.Turn < g.Turn-10 {
+			delete(g.HeardNoise, p)
+		}
+	}
+}
+
+func (hn heardNoise) String() string {
+	switch {
+	case hn.Volume >= LoudNoise:
+		return "a loud noise"
+	case hn.Volume >= LoudNoise/2:
+		return "a noise"
+	default:
+		return "a faint noise"
+	}
+}
+
+func (hn heardNoise) Color() uicolor {
+	if hn.Volume >= LoudNoise {
+		return ColorFgMonster
+	}
+	return ColorFgWanderingMonster
+}
+
+// FootstepNoise returns the noise made by the player when moving, depending
+// on the armour.
+func (g *game) FootstepNoise() int {
+	switch g.Player.Armour {
+	case ShinyPlates:
+		return 4
+	case TurtlePlates:
+		return 5
+	default:
+		return 0
+	}
+}
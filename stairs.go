@@ -5,4 +5,6 @@ type stair int
 const (
 	NormalStair stair = iota
 	WinStair
+	UpStair
+	BranchStair
 )
@@ -1,34 +1,43 @@
 package main
 
 type stats struct {
-	Story         []string
-	Killed        int
-	KilledMons    map[monsterKind]int
-	Moves         int
-	Hits          int
-	Misses        int
-	ReceivedHits  int
-	Dodges        int
-	Blocks        int
-	Drinks        int
-	Evocations    int
-	UsedStones    int
-	Throws        int
-	TimesLucky    int
-	Damage        int
-	DExplPerc     [MaxDepth + 1]int
-	DSleepingPerc [MaxDepth + 1]int
-	DKilledPerc   [MaxDepth + 1]int
-	DLayout       [MaxDepth + 1]string
-	Burns         int
-	Digs          int
-	Rest          int
-	RestInterrupt int
-	Turns         int
-	TWounded      int
-	TMWounded     int
-	TMonsLOS      int
-	UsedRod       [NumRods]int
+	Story            []string
+	Killed           int
+	KilledMons       map[monsterKind]int
+	AllyKills        int
+	InfightingKills  int
+	EnvironmentKills int
+	Moves            int
+	Hits             int
+	Misses           int
+	ReceivedHits     int
+	Dodges           int
+	Blocks           int
+	Drinks           int
+	Evocations       int
+	UsedStones       int
+	TriggeredTraps   int
+	DiscoveredTraps  int
+	MonsterTraps     int
+	Throws           int
+	TimesLucky       int
+	Damage           int
+	DExplPerc        []int
+	DSleepingPerc    []int
+	DKilledPerc      []int
+	DLayout          []string
+	Burns            int
+	Burned           [NumFuels]int
+	Digs             int
+	Rest             int
+	RestInterrupt    int
+	Turns            int
+	TWounded         int
+	TMWounded        int
+	TMonsLOS         int
+	UsedRod          [NumRods]int
+	SpentSimellas    int
+	Purchases        [NumWareKinds]int
 }
 
 func (g *game) TurnStats() {
@@ -45,7 +54,21 @@ func (g *game) TurnStats() {
 	}
 }
 
+// DepthStats makes room for per-depth statistics up to the current depth.
+func (g *game) DepthStats() {
+	for len(g.Stats.DLayout) <= g.Depth {
+		g.Stats.DExplPerc = append(g.Stats.DExplPerc, 0)
+		g.Stats.DSleepingPerc = append(g.Stats.DSleepingPerc, 0)
+		g.Stats.DKilledPerc = append(g.Stats.DKilledPerc, 0)
+		g.Stats.DLayout = append(g.Stats.DLayout, "")
+	}
+}
+
 func (g *game) LevelStats() {
+	if g.Branch != NoBranch {
+		// only main dungeon levels are recorded
+		return
+	}
 	free := 0
 	exp := 0
 	for _, c := range g.Dungeon.Cells {
@@ -20,106 +20,465 @@ const (
 	StatusShadows
 	StatusSlay
 	StatusAccurate
+	StatusNet
+	StatusEnraged
+	StatusPoison
+	StatusBleeding
+	StatusDark // fake status
 )
 
-func (st status) Good() bool {
-	switch st {
-	case StatusBerserk, StatusSwift, StatusAgile, StatusDig, StatusSwap, StatusShadows, StatusSlay, StatusAccurate:
-		return true
-	default:
-		return false
+const NumStatuses = int(StatusDark) + 1
+
+// StatusTickDelay is the delay between two ticks of a damage-over-time
+// status.
+const StatusTickDelay = 10
+
+// statusStacking describes what happens when a status is applied to a
+// creature that already has it.
+type statusStacking int
+
+const (
+	StackIgnore statusStacking = iota // the status is not applied again
+	StackCount                        // a new instance with its own duration is added
+)
+
+// statusData describes a status effect, shared by the player and monsters.
+// Hooks are optional: apply hooks are called each time the status is
+// applied, and expire hooks each time an instance of the status ends, after
+// its count has been decremented. Statuses with tick hooks are
+// damage-over-time effects: each instance calls the hook every
+// StatusTickDelay until its end.
+type statusData struct {
+	name       string
+	short      string
+	adj        string // for monster descriptions
+	good       bool
+	bad        bool
+	duration   int
+	random     int // random additional duration
+	stacking   statusStacking
+	healable   bool   // cured by healing and resting
+	endMsg     string // for the player
+	monsEndMsg string // for monsters, formatted with the monster's name
+	apply      func(g *game, ev event)
+	expire     func(g *game, ev event)
+	monsApply  func(g *game, m *monster, ev event)
+	monsExpire func(g *game, m *monster, ev event)
+	tick       func(g *game, ev event)
+	monsTick   func(g *game, m *monster, mev *monsterEvent)
+}
+
+var StatusData map[status]*statusData
+
+func init() {
+	StatusData = map[status]*statusData{
+		StatusBerserk: {
+			name: "Berserk", short: "Be", adj: "berserk", good: true,
+			duration: 65, random: 20,
+			endMsg:     "You are no longer berserk.",
+			monsEndMsg: "%s is no longer berserk.",
+			apply: func(g *game, ev event) {
+				g.Player.HP += 10
+			},
+			expire: func(g *game, ev event) {
+				g.PutStatusFor(StatusSlow, 90+RandInt(30), ev)
+				g.PutStatusFor(StatusExhausted, 270+RandInt(60), ev)
+				g.Player.HP -= int(10 * g.Player.HP / Max(g.Player.HPMax(), g.Player.HP))
+			},
+			monsExpire: func(g *game, m *monster, ev event) {
+				m.PutStatusFor(g, StatusSlow, 90+RandInt(30), ev)
+				m.PutStatusFor(g, StatusExhausted, 100+RandInt(50), ev)
+			},
+		},
+		StatusSlow: {
+			name: "Slow", short: "Sl", adj: "slowed", bad: true,
+			duration: 30, random: 10, stacking: StackCount,
+			endMsg:     "You no longer feel slow.",
+			monsEndMsg: "%s is no longer slowed.",
+		},
+		StatusExhausted: {
+			name: "Exhausted", short: "Ex", adj: "exhausted",
+			duration: 100, random: 50, stacking: StackCount,
+			endMsg: "You no longer feel exhausted.",
+		},
+		StatusSwift: {
+			name: "Swift", short: "Sw", adj: "hasted", good: true,
+			duration: 85, random: 20, stacking: StackCount,
+			endMsg:     "You no longer feel speedy.",
+			monsEndMsg: "%s is no longer hasted.",
+		},
+		StatusAgile: {
+			name: "Agile", short: "Ag", adj: "agile", good: true,
+			duration: 85, random: 20, stacking: StackCount,
+			endMsg: "You no longer feel agile.",
+		},
+		StatusLignification: {
+			name: "Lignified", short: "Li", adj: "lignified",
+			duration: 150, random: 100,
+			endMsg:     "You no longer feel attached to the ground.",
+			monsEndMsg: "%s is no longer lignified.",
+			apply: func(g *game, ev event) {
+				g.Player.HP += 10
+			},
+			expire: func(g *game, ev event) {
+				g.Player.HP -= int(10 * g.Player.HP / Max(g.Player.HPMax(), g.Player.HP))
+			},
+			monsApply: func(g *game, m *monster, ev event) {
+				m.Path = m.Path[:0]
+			},
+			monsExpire: func(g *game, m *monster, ev event) {
+				m.Path = m.APath(g, m.P, m.Target)
+			},
+		},
+		StatusConfusion: {
+			name: "Confused", short: "Co", adj: "confused", bad: true,
+			duration: 100, random: 100,
+			endMsg:     "You no longer feel confused.",
+			monsEndMsg: "%s is no longer confused.",
+			monsApply: func(g *game, m *monster, ev event) {
+				m.Path = m.Path[:0]
+			},
+			monsExpire: func(g *game, m *monster, ev event) {
+				m.Path = m.APath(g, m.P, m.Target)
+			},
+		},
+		StatusTele: {
+			name: "Tele", short: "Te", adj: "unstable",
+			duration: 20, random: 30,
+			expire: func(g *game, ev event) {
+				if !g.Player.HasStatus(StatusLignification) {
+					g.Teleportation(ev)
+				} else {
+					g.Print("Lignification has prevented teleportation.")
+				}
+			},
+			monsExpire: func(g *game, m *monster, ev event) {
+				if !m.Status(StatusLignification) {
+					m.TeleportAway(g, ev)
+				}
+			},
+		},
+		StatusNausea: {
+			name: "Nausea", short: "Na", adj: "sick", bad: true,
+			duration: 30, random: 20,
+			endMsg: "You no longer feel sick.",
+		},
+		StatusDisabledShield: {
+			name: "-Shield", short: "-S", adj: "shieldless", bad: true,
+			duration: 100, random: 100,
+			endMsg: "You manage to dislodge the projectile from your shield.",
+		},
+		StatusCorrosion: {
+			name: "Corroded", short: "Co", adj: "corroded", bad: true,
+			duration: 80, random: 40, stacking: StackCount,
+			endMsg: "Your equipment is now free from acid.",
+		},
+		StatusFlames: {
+			name: "Flames", short: "Fl", adj: "burning", bad: true,
+		},
+		StatusDig: {
+			name: "Dig", short: "Di", adj: "digging", good: true,
+			duration: 75, random: 20, stacking: StackCount,
+			endMsg: "You no longer feel like an earth dragon.",
+		},
+		StatusSwap: {
+			name: "Swap", short: "Sw", adj: "light-footed", good: true,
+			duration: 130, random: 41, stacking: StackCount,
+			endMsg: "You no longer feel light-footed.",
+		},
+		StatusShadows: {
+			name: "Shadows", short: "Sh", adj: "in shadows", good: true,
+			duration: 130, random: 41,
+			endMsg:     "The shadows leave you.",
+			monsEndMsg: "%s is no longer in shadows.",
+			apply: func(g *game, ev event) {
+				g.ComputeLOS()
+			},
+			expire: func(g *game, ev event) {
+				g.ComputeLOS()
+				g.MakeMonstersAware()
+			},
+		},
+		StatusSlay: {
+			name: "Slay", short: "Sl", adj: "slaying", good: true,
+			duration: 60, stacking: StackCount,
+			endMsg: "You no longer feel extra slaying power.",
+			expire: func(g *game, ev event) {
+				if !g.Player.HasStatus(StatusSlay) {
+					g.ComputeLOS()
+					g.MakeMonstersAware()
+				}
+			},
+		},
+		StatusAccurate: {
+			name: "Accurate", short: "Ac", adj: "accurate", good: true,
+			duration: 85, random: 20, stacking: StackCount,
+			endMsg: "You no longer feel accurate.",
+		},
+		StatusNet: {
+			name: "Net", short: "Ne", adj: "netted", bad: true,
+			duration: 50, random: 30, stacking: StackCount,
+			endMsg: "You free yourself from the net.",
+		},
+		StatusEnraged: {
+			name: "Enraged", short: "En", adj: "enraged", bad: true,
+			duration: 60, random: 40,
+			monsEndMsg: "%s is no longer enraged.",
+			monsApply: func(g *game, m *monster, ev event) {
+				if m.State == Resting || m.State == Fleeing {
+					m.State = Hunting
+				}
+				m.Path = nil
+			},
+			monsExpire: func(g *game, m *monster, ev event) {
+				if !m.Ally && m.State == Hunting {
+					m.Target = g.Player.P
+				}
+				m.Path = nil
+			},
+		},
+		StatusPoison: {
+			name: "Poisoned", short: "Po", adj: "poisoned", bad: true,
+			duration: 50, random: 30, stacking: StackCount, healable: true,
+			endMsg:     "You are no longer poisoned.",
+			monsEndMsg: "%s is no longer poisoned.",
+			tick: func(g *game, ev event) {
+				// poison weakens, but does not kill
+				if g.Player.HP > 1 {
+					g.Player.HP--
+					g.Stats.Damage++
+				}
+			},
+			monsTick: func(g *game, m *monster, mev *monsterEvent) {
+				m.DamageOverTime(g, 1, "poison", mev)
+			},
+		},
+		StatusBleeding: {
+			name: "Bleeding", short: "Bl", adj: "bleeding", bad: true,
+			duration: 30, random: 20, stacking: StackCount, healable: true,
+			endMsg:     "You are no longer bleeding.",
+			monsEndMsg: "%s is no longer bleeding.",
+			tick: func(g *game, ev event) {
+				g.Player.HP -= 2
+				g.Stats.Damage += 2
+				g.StopAuto()
+				if g.Player.HP <= 0 {
+					g.PrintStyled("You bleed to death.", logCritic)
+					g.StoryPrint("Bled to death.")
+				}
+			},
+			monsTick: func(g *game, m *monster, mev *monsterEvent) {
+				m.DamageOverTime(g, 2, "blood loss", mev)
+			},
+		},
+		StatusDark: {
+			name: "Dark", short: "Dk", adj: "in darkness", good: true,
+		},
 	}
 }
 
+func (st status) Good() bool {
+	return StatusData[st].good
+}
+
 func (st status) Bad() bool {
-	switch st {
-	case StatusSlow, StatusConfusion, StatusNausea, StatusDisabledShield, StatusFlames, StatusCorrosion:
-		return true
-	default:
-		return false
-	}
+	return StatusData[st].bad
 }
 
 func (st status) String() string {
-	switch st {
-	case StatusBerserk:
-		return "Berserk"
-	case StatusSlow:
-		return "Slow"
-	case StatusExhausted:
-		return "Exhausted"
-	case StatusSwift:
-		return "Swift"
-	case StatusLignification:
-		return "Lignified"
-	case StatusAgile:
-		return "Agile"
-	case StatusConfusion:
-		return "Confused"
-	case StatusTele:
-		return "Tele"
-	case StatusNausea:
-		return "Nausea"
-	case StatusDisabledShield:
-		return "-Shield"
-	case StatusCorrosion:
-		return "Corroded"
-	case StatusFlames:
-		return "Flames"
-	case StatusDig:
-		return "Dig"
-	case StatusSwap:
-		return "Swap"
-	case StatusShadows:
-		return "Shadows"
-	case StatusSlay:
-		return "Slay"
-	case StatusAccurate:
-		return "Accurate"
-	default:
+	sd, ok := StatusData[st]
+	if !ok {
 		// should not happen
 		return "unknown"
 	}
+	return sd.name
 }
 
 func (st status) Short() string {
-	switch st {
-	case StatusBerserk:
-		return "Be"
-	case StatusSlow:
-		return "Sl"
-	case StatusExhausted:
-		return "Ex"
-	case StatusSwift:
-		return "Sw"
-	case StatusLignification:
-		return "Li"
-	case StatusAgile:
-		return "Ag"
-	case StatusConfusion:
-		return "Co"
-	case StatusTele:
-		return "Te"
-	case StatusNausea:
-		return "Na"
-	case StatusDisabledShield:
-		return "-S"
-	case StatusCorrosion:
-		return "Co"
-	case StatusFlames:
-		return "Fl"
-	case StatusDig:
-		return "Di"
-	case StatusSwap:
-		return "Sw"
-	case StatusShadows:
-		return "Sh"
-	case StatusSlay:
-		return "Sl"
-	case StatusAccurate:
-		return "Ac"
-	default:
+	sd, ok := StatusData[st]
+	if !ok {
 		// should not happen
 		return "?"
 	}
+	return sd.short
+}
+
+// Adjective returns the status as shown in monster descriptions.
+func (st status) Adjective() string {
+	return StatusData[st].adj
+}
+
+// Duration returns a random default duration for the status.
+func (st status) Duration() int {
+	sd := StatusData[st]
+	return sd.duration + RandInt(sd.random)
+}
+
+// PutStatus applies a status to the player for its default duration. It
+// returns false if the status was not applied because the player already has
+// it and it does not stack.
+func (g *game) PutStatus(st status, ev event) bool {
+	return g.PutStatusFor(st, st.Duration(), ev)
+}
+
+// PutStatusFor is like PutStatus, but with a custom duration.
+func (g *game) PutStatusFor(st status, duration int, ev event) bool {
+	sd := StatusData[st]
+	if sd.stacking == StackIgnore && g.Player.HasStatus(st) {
+		return false
+	}
+	g.Player.Statuses[st]++
+	end := ev.Rank() + duration
+	if end > g.Player.Expire[st] {
+		g.Player.Expire[st] = end
+	}
+	if sd.tick != nil {
+		g.PushEvent(&simpleEvent{ERank: ev.Rank() + StatusTickDelay, EAction: StatusTick, Status: st,
+			Ticks: Max(duration/StatusTickDelay, 1), Epoch: g.Player.StatusEpoch[st]})
+	} else {
+		g.PushEvent(&simpleEvent{ERank: end, EAction: StatusEnd, Status: st, Epoch: g.Player.StatusEpoch[st]})
+	}
+	if sd.apply != nil {
+		sd.apply(g, ev)
+	}
+	return true
+}
+
+// ExpireStatus ends an instance of a player's status.
+func (g *game) ExpireStatus(st status, ev event) {
+	if g.Player.Statuses[st] <= 0 {
+		// the status was removed before its end
+		return
+	}
+	g.Player.Statuses[st]--
+	sd := StatusData[st]
+	if sd.expire != nil {
+		sd.expire(g, ev)
+	}
+	if g.Player.Statuses[st] == 0 && sd.endMsg != "" {
+		g.PrintStyled(sd.endMsg, logStatusEnd)
+		g.ui.StatusEndAnimation()
+	}
+}
+
+// TickStatus applies a tick of a damage-over-time status instance to the
+// player. The instance ends after its last tick.
+func (g *game) TickStatus(sev *simpleEvent) {
+	if sev.Epoch != g.Player.StatusEpoch[sev.Status] {
+		// cured
+		return
+	}
+	StatusData[sev.Status].tick(g, sev)
+	sev.Ticks--
+	if sev.Ticks > 0 {
+		sev.Renew(g, StatusTickDelay)
+		return
+	}
+	g.ExpireStatus(sev.Status, sev)
+}
+
+// CureStatuses removes the player's healable statuses. Pending events of
+// the cured instances are ignored.
+func (g *game) CureStatuses() {
+	for i := 0; i < NumStatuses; i++ {
+		st := status(i)
+		if g.Player.Statuses[st] > 0 && StatusData[st].healable {
+			g.Player.Statuses[st] = 0
+			g.Player.StatusEpoch[st]++
+			g.PrintStyled(StatusData[st].endMsg, logStatusEnd)
+		}
+	}
+}
+
+func (m *monster) Status(st status) bool {
+	return m.Statuses[st] > 0
+}
+
+// PutStatus applies a status to the monster for its default duration. It
+// returns false if the status was not applied because the monster already has
+// it and it does not stack.
+func (m *monster) PutStatus(g *game, st status, ev event) bool {
+	return m.PutStatusFor(g, st, st.Duration(), ev)
+}
+
+// PutStatusFor is like PutStatus, but with a custom duration.
+func (m *monster) PutStatusFor(g *game, st status, duration int, ev event) bool {
+	return m.PutStatusFrom(g, st, duration, KillByPlayer, ev)
+}
+
+// PutStatusFrom is like PutStatusFor, but with a given source, to which
+// kills by damage-over-time statuses are attributed.
+func (m *monster) PutStatusFrom(g *game, st status, duration int, src killSource, ev event) bool {
+	sd := StatusData[st]
+	if sd.stacking == StackIgnore && m.Status(st) {
+		return false
+	}
+	m.Statuses[st]++
+	if sd.monsTick != nil {
+		g.PushEvent(&monsterEvent{ERank: ev.Rank() + StatusTickDelay, NMons: m.Index, EAction: MonsStatusTick, Status: st,
+			Ticks: Max(duration/StatusTickDelay, 1), Source: src, Epoch: m.StatusEpoch[st]})
+	} else {
+		g.PushEvent(&monsterEvent{ERank: ev.Rank() + duration, NMons: m.Index, EAction: MonsStatusEnd, Status: st,
+			Epoch: m.StatusEpoch[st]})
+	}
+	if sd.monsApply != nil {
+		sd.monsApply(g, m, ev)
+	}
+	return true
+}
+
+// ExpireStatus ends an instance of a monster's status.
+func (m *monster) ExpireStatus(g *game, st status, ev event) {
+	if m.Statuses[st] <= 0 {
+		return
+	}
+	m.Statuses[st]--
+	sd := StatusData[st]
+	if sd.monsExpire != nil {
+		sd.monsExpire(g, m, ev)
+	}
+	if m.Statuses[st] == 0 && sd.monsEndMsg != "" && g.Player.LOS[m.P] {
+		g.Printf(sd.monsEndMsg, m.Kind.Definite(true))
+	}
+}
+
+// TickStatus applies a tick of a damage-over-time status instance to the
+// monster.
+func (m *monster) TickStatus(g *game, mev *monsterEvent) {
+	if mev.Epoch != m.StatusEpoch[mev.Status] {
+		// cured
+		return
+	}
+	StatusData[mev.Status].monsTick(g, m, mev)
+	if !m.Exists() {
+		return
+	}
+	mev.Ticks--
+	if mev.Ticks > 0 {
+		mev.Renew(g, StatusTickDelay)
+		return
+	}
+	m.ExpireStatus(g, mev.Status, mev)
+}
+
+// CureStatuses removes the monster's healable statuses. Pending events of
+// the cured instances are ignored.
+func (m *monster) CureStatuses() {
+	for st, c := range m.Statuses {
+		if c > 0 && StatusData[status(st)].healable {
+			m.Statuses[st] = 0
+			m.StatusEpoch[st]++
+		}
+	}
+}
+
+// DamageOverTime inflicts damage from a damage-over-time status to the
+// monster. A kill is attributed to the source of the status.
+func (m *monster) DamageOverTime(g *game, damage int, cause string, mev *monsterEvent) {
+	if !g.DamageMonster(m, damage, mev.Source, mev) {
+		return
+	}
+	if g.Player.LOS[m.P] {
+		g.PrintfStyled("%s dies from %s.", logPlayerHit, m.Kind.Definite(true), cause)
+	}
 }
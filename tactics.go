@@ -0,0 +1,129 @@
+package main
+
+import "codeberg.org/anaseto/gruid"
+
+// bandTactics describes how the members of a band coordinate while hunting
+// the player. Tactics are flags that can be combined.
+type bandTactics int
+
+const (
+	// TacticSurround makes band members spread over the cells around the
+	// player instead of queuing behind each other.
+	TacticSurround bandTactics = 1 << iota
+	// TacticRangedBehind makes ranged members keep distance behind melee
+	// ones.
+	TacticRangedBehind
+	// TacticAmbush makes band members wait outside doorways near the
+	// player, instead of walking through them.
+	TacticAmbush
+)
+
+func (m *monster) Tactics(g *game) bandTactics {
+	return m.BandInfo(g).Tactics
+}
+
+// BandMates returns the other existing monsters of the monster's band.
+func (m *monster) BandMates(g *game) []*monster {
+	if !m.BandInfo(g).Band {
+		return nil
+	}
+	mates := []*monster{}
+	for _, mons := range g.Monsters {
+		if mons.Index != m.Index && mons.Band == m.Band && mons.Exists() {
+			mates = append(mates, mons)
+		}
+	}
+	return mates
+}
+
+// Surround changes the monster's target to a free cell around the player
+// that is not already taken or aimed at by another hunting band member.
+func (m *monster) Surround(g *game) {
+	if m.Target != g.Player.P || Distance(m.P, g.Player.P) <= 1 {
+		return
+	}
+	mates := m.BandMates(g)
+	taken := func(p gruid.Point) bool {
+		if g.MonsterAt(p).Exists() {
+			return true
+		}
+		for _, mons := range mates {
+			if mons.State == Hunting && mons.Target == p {
+				return true
+			}
+		}
+		return false
+	}
+	best := InvalidPos
+	bestd := 0
+	for _, p := range g.Dungeon.FreeNeighbors(g.Player.P) {
+		if taken(p) {
+			continue
+		}
+		d := Distance(m.P, p)
+		if !valid(best) || d < bestd {
+			best = p
+			bestd = d
+		}
+	}
+	if valid(best) {
+		m.Target = best
+	}
+}
+
+// KeepDistance makes a ranged monster step back when a melee band member
+// is already fighting the player. It returns true if the monster moved.
+func (m *monster) KeepDistance(g *game, ev event, movedelay int) bool {
+	p, ok := m.KeepDistanceCell(g)
+	if !ok {
+		return false
+	}
+	m.Path = nil
+	if m.MoveTo(g, p, ev) || m.Exists() {
+		ev.Renew(g, movedelay)
+	}
+	return true
+}
+
+// KeepDistanceCell returns the cell to which the monster would step back in
+// KeepDistance, if any.
+func (m *monster) KeepDistanceCell(g *game) (gruid.Point, bool) {
+	d := Distance(m.P, g.Player.P)
+	if d > 2 || m.Status(StatusLignification) || m.Status(StatusConfusion) {
+		return InvalidPos, false
+	}
+	covered := false
+	for _, mons := range m.BandMates(g) {
+		if mons.State == Hunting && !mons.Kind.Ranged() && Distance(mons.P, g.Player.P) == 1 {
+			covered = true
+			break
+		}
+	}
+	if !covered {
+		return InvalidPos, false
+	}
+	for _, p := range g.Dungeon.FreeNeighbors(m.P) {
+		if Distance(p, g.Player.P) <= d || !g.Player.LOS[p] || g.MonsterAt(p).Exists() || m.DoorBlocks(g, p) {
+			continue
+		}
+		if _, ok := g.Traps[p]; ok {
+			continue
+		}
+		return p, true
+	}
+	return InvalidPos, false
+}
+
+// Ambush reports whether the monster should wait next to a door instead of
+// walking through it, because the player is close on the other side.
+func (m *monster) Ambush(g *game) bool {
+	if g.Player.LOS[m.P] || len(m.Path) < 2 {
+		return false
+	}
+	door := m.Path[1]
+	if _, ok := g.Doors[door]; !ok || Distance(door, g.Player.P) > 4 {
+		return false
+	}
+	// do not wait forever
+	return RandInt(5) > 0
+}
@@ -140,6 +140,7 @@ var MapNames = map[rune]string{
 	')':  "rparen",
 	'(':  "lparen",
 	'>':  "stairs",
+	'<':  "stairs",
 	'Δ':  "portal",
 	'!':  "potion",
 	';':  "semicolon",
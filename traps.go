@@ -0,0 +1,193 @@
+package main
+
+import "codeberg.org/anaseto/gruid"
+
+type trap int
+
+const (
+	AlarmTrap trap = iota
+	TeleportTrap
+	NetTrap
+	FireTrap
+	GasTrap
+	SteamTrap
+)
+
+const NumTraps = int(SteamTrap) + 1
+
+func (t trap) String() (text string) {
+	switch t {
+	case AlarmTrap:
+		text = "alarm trap"
+	case TeleportTrap:
+		text = "teleport trap"
+	case NetTrap:
+		text = "net trap"
+	case FireTrap:
+		text = "fire trap"
+	case GasTrap:
+		text = "gas trap"
+	case SteamTrap:
+		text = "steam trap"
+	}
+	return text
+}
+
+func (t trap) Description() (text string) {
+	switch t {
+	case AlarmTrap:
+		text = "A creature stepping on an alarm trap triggers a loud ringing that will attract monsters."
+	case TeleportTrap:
+		text = "A creature stepping on a teleport trap will be teleported away."
+	case NetTrap:
+		text = "A net will fall on any creature stepping on the net trap, preventing it from moving for some time."
+	case FireTrap:
+		text = "Flames will burst out of the ground when a creature steps on the fire trap, burning any nearby foliage."
+	case GasTrap:
+		text = "A cloud of poison gas will come out of the ground when a creature steps on the gas trap."
+	case SteamTrap:
+		text = "Scalding steam will burst out of the ground when a creature steps on the steam trap."
+	}
+	text += " Traps are used up once triggered."
+	return text
+}
+
+const AlarmNoise = 25
+
+func (g *game) GenTraps() {
+	if g.Depth < 2 {
+		return
+	}
+	ntraps := RandInt(2 + g.DangerDepth()/3)
+	for i := 0; i < ntraps; i++ {
+		p := g.FreeCellForStatic()
+		var t trap
+		switch RandInt(10) {
+		case 0, 1, 2:
+			t = AlarmTrap
+		case 3, 4:
+			t = NetTrap
+		case 5, 6:
+			t = TeleportTrap
+		case 7:
+			t = FireTrap
+		case 8:
+			t = GasTrap
+		default:
+			t = SteamTrap
+		}
+		g.Traps[p] = t
+	}
+}
+
+// SearchTraps gives a chance to discover traps in view. Adjacent traps are
+// always noticed.
+func (g *game) SearchTraps() {
+	// iterate in cell order, as map order is random and would break daily
+	// challenge determinism
+	for i := range g.Dungeon.Cells {
+		p := idx2Point(i)
+		t, ok := g.Traps[p]
+		if !ok || g.KnownTraps[p] || !g.Player.LOS[p] {
+			continue
+		}
+		if Distance(p, g.Player.P) > 1 && RandInt(4) > 0 {
+			continue
+		}
+		g.KnownTraps[p] = true
+		g.Stats.DiscoveredTraps++
+		g.Printf("You discover %s.", Indefinite(t.String(), false))
+		g.InvalidatePathMaps()
+		g.StopAuto()
+	}
+}
+
+// TriggerTrap triggers the trap at the player's position, if any.
+func (g *game) TriggerTrap(ev event) {
+	p := g.Player.P
+	t, ok := g.Traps[p]
+	if !ok {
+		return
+	}
+	g.RemoveTrap(p)
+	g.Stats.TriggeredTraps++
+	g.StoryPrintf("Triggered %s.", Indefinite(t.String(), false))
+	g.StopAuto()
+	switch t {
+	case AlarmTrap:
+		g.PrintStyled("You step on an alarm trap. A loud ringing resonates!", logCritic)
+		g.MakeNoise(AlarmNoise, p)
+	case TeleportTrap:
+		g.PrintStyled("You step on a teleport trap.", logCritic)
+		g.Teleportation(ev)
+	case NetTrap:
+		g.PrintStyled("You step on a net trap. You are caught in a net!", logCritic)
+		g.PutStatus(StatusNet, ev)
+	case FireTrap:
+		g.PrintStyled("You step on a fire trap. Flames burst out of the ground!", logCritic)
+		g.FireTrap(p, ev)
+	case GasTrap:
+		g.PrintStyled("You step on a gas trap. Poison gas comes out of the ground!", logCritic)
+		g.ReleaseGas(p, CloudPoison, ev)
+	case SteamTrap:
+		g.PrintStyled("You step on a steam trap. Scalding steam bursts out of the ground!", logCritic)
+		g.ReleaseGas(p, CloudSteam, ev)
+	}
+}
+
+// MonsterTriggerTrap triggers the trap at the monster's position, if any.
+func (g *game) MonsterTriggerTrap(m *monster, ev event) {
+	p := m.P
+	t, ok := g.Traps[p]
+	if !ok {
+		return
+	}
+	seen := g.Player.LOS[p]
+	if seen {
+		g.KnownTraps[p] = true
+		g.Printf("%s triggers %s.", m.Kind.Definite(true), Indefinite(t.String(), false))
+	}
+	g.RemoveTrap(p)
+	g.Stats.MonsterTraps++
+	switch t {
+	case AlarmTrap:
+		if !seen {
+			g.Print("You hear a loud ringing.")
+		}
+		g.MakeNoise(AlarmNoise, p)
+	case TeleportTrap:
+		m.TeleportAway(g, ev)
+	case NetTrap:
+		if m.PutStatusFor(g, StatusLignification, 50+RandInt(30), ev) {
+			if seen {
+				g.Printf("%s is caught in a net.", m.Kind.Definite(true))
+			}
+		}
+	case FireTrap:
+		g.FireTrap(p, ev)
+	case GasTrap:
+		g.ReleaseGas(p, CloudPoison, ev)
+	case SteamTrap:
+		g.ReleaseGas(p, CloudSteam, ev)
+	}
+}
+
+func (g *game) RemoveTrap(p gruid.Point) {
+	delete(g.Traps, p)
+	delete(g.KnownTraps, p)
+	g.InvalidatePathMaps()
+}
+
+// FireTrap makes flames burst at a given position, burning the creature there
+// and nearby foliage.
+func (g *game) FireTrap(p gruid.Point, ev event) {
+	if _, ok := g.Clouds[p]; !ok {
+		g.Clouds[p] = CloudFire
+		g.PushEvent(&cloudEvent{ERank: ev.Rank() + 10, EAction: FireProgression, P: p, Burning: 2 + RandInt(3)})
+	}
+	g.BurnCreature(p, ev)
+	for _, q := range g.Dungeon.FreeNeighbors(p) {
+		g.Burn(q, ev)
+	}
+	g.ComputeLOS()
+}
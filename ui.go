@@ -221,7 +221,11 @@ func (ui *gameui) PlayerTurnEvent(ev event) (err error, again, quit bool) {
 }
 
 func (ui *gameui) Scroll(n int) (m int, quit bool) {
-	in := ui.PollEvent()
+	return ui.ScrollInput(ui.PollEvent(), n)
+}
+
+// ScrollInput handles a scrolling input event.
+func (ui *gameui) ScrollInput(in uiInput, n int) (m int, quit bool) {
 	switch in.key {
 	case "Escape", "\x1b", " ", "x", "X":
 		quit = true
@@ -571,6 +575,7 @@ const (
 	KeyMenuCommandHelp
 	KeyMenuTargetingHelp
 	KeyInventory
+	KeyInteract
 )
 
 var configurableKeyActions = [...]keyAction{
@@ -613,6 +618,7 @@ var configurableKeyActions = [...]keyAction{
 	KeyTarget,
 	KeyExclude,
 	KeyInventory,
+	KeyInteract,
 }
 
 var CustomKeys bool
@@ -653,7 +659,8 @@ func (k keyAction) NormalModeKey() bool {
 		KeyConfigure,
 		KeyWizard,
 		KeyWizardInfo,
-		KeyInventory:
+		KeyInventory,
+		KeyInteract:
 		return true
 	default:
 		return false
@@ -699,7 +706,7 @@ func (k keyAction) NormalModeDescription() (text string) {
 	case KeyWaitTurn:
 		text = "Wait a turn"
 	case KeyDescend:
-		text = "Descend stairs"
+		text = "Take stairs"
 	case KeyGoToStairs:
 		text = "Go to nearest stairs"
 	case KeyExplore:
@@ -740,6 +747,8 @@ func (k keyAction) NormalModeDescription() (text string) {
 		text = "Action Menu"
 	case KeyInventory:
 		text = "See Inventory"
+	case KeyInteract:
+		text = "Use dungeon feature"
 	}
 	return text
 }
@@ -855,6 +864,7 @@ func ApplyDefaultKeyBindings() {
 		'5': KeyWaitTurn,
 		'r': KeyRest,
 		'>': KeyDescend,
+		'<': KeyDescend,
 		'D': KeyDescend,
 		'G': KeyGoToStairs,
 		'o': KeyExplore,
@@ -865,6 +875,7 @@ func ApplyDefaultKeyBindings() {
 		'q': KeyDrink,
 		'd': KeyDrink,
 		'i': KeyInventory,
+		'a': KeyInteract,
 		't': KeyThrow,
 		'f': KeyThrow,
 		'v': KeyEvoke,
@@ -906,6 +917,7 @@ func ApplyDefaultKeyBindings() {
 		'B':    KeyRunSW,
 		'N':    KeyRunSE,
 		'>':    KeyNextStairs,
+		'<':    KeyNextStairs,
 		'-':    KeyPreviousMonster,
 		'+':    KeyNextMonster,
 		'o':    KeyNextObject,
@@ -962,7 +974,7 @@ func (ui *gameui) HandleKeyAction(rka runeKeyAction) (err error, again bool, qui
 
 func (ui *gameui) OptionalDescendConfirmation(st stair) (err error) {
 	g := ui.g
-	if g.Depth == WinDepth && st == NormalStair {
+	if g.Depth == WinDepth && st == NormalStair && g.Branch == NoBranch {
 		g.Print("Do you really want to dive into optional depths? [y/N]")
 		ui.DrawDungeonView(NormalMode)
 		dive := ui.PromptConfirmation()
@@ -974,6 +986,32 @@ func (ui *gameui) OptionalDescendConfirmation(st stair) (err error) {
 
 }
 
+// ChooseAptitude makes the player choose a new aptitude for the oldest
+// pending choice. The choice can be postponed: it is then asked again at the
+// start of the next turn.
+func (ui *gameui) ChooseAptitude() {
+	g := ui.g
+	apts := g.PendingAptitudes()
+	if len(apts) == 0 {
+		return
+	}
+	ui.DrawDungeonView(NormalMode)
+	apt, err := ui.SelectAptitude(apts, true)
+	if err != nil {
+		g.Print("You postpone your choice of a new aptitude. You will be asked again next turn.")
+		return
+	}
+	g.ApplyChosenAptitude(apt)
+}
+
+// OfferingConfirmation asks the player to confirm an offering of simellas.
+func (ui *gameui) OfferingConfirmation(cost int) bool {
+	g := ui.g
+	g.Printf("Do you really want to offer %d simellas? [y/N]", cost)
+	ui.DrawDungeonView(NormalMode)
+	return ui.PromptConfirmation()
+}
+
 func (ui *gameui) HandleKey(rka runeKeyAction) (err error, again bool, quit bool) {
 	g := ui.g
 	switch rka.k {
@@ -1028,6 +1066,10 @@ func (ui *gameui) HandleKey(rka runeKeyAction) (err error, again bool, quit bool
 	case KeyInventory:
 		ui.ViewAll()
 		again = true
+	case KeyInteract:
+		err = g.UseFeature(g.Ev)
+		ui.MenuSelectedAnimation(MenuInteract, err == nil)
+		err = ui.CleanError(err)
 	case KeyDrink:
 		err = ui.SelectPotion(g.Ev)
 		err = ui.CleanError(err)
@@ -1378,7 +1420,7 @@ func (ui *gameui) CursorAction(targ Targeter, start *gruid.Point) (err error, ag
 	} else {
 		minDist := 999
 		for _, mons := range g.Monsters {
-			if mons.Exists() && g.Player.LOS[mons.P] {
+			if mons.Exists() && !mons.Ally && g.Player.LOS[mons.P] {
 				dist := Distance(mons.P, g.Player.P)
 				if minDist > dist {
 					minDist = dist
@@ -1493,6 +1535,8 @@ func (m menu) Key(g *game) (key keyAction) {
 			key = KeyEquip
 		} else if _, ok := g.Stairs[g.Player.P]; ok {
 			key = KeyDescend
+		} else if _, ok := g.Features[g.Player.P]; ok {
+			key = KeyInteract
 		}
 	}
 	return key
@@ -1547,6 +1591,12 @@ func (ui *gameui) UpdateInteractButton() string {
 	} else if _, ok := g.Stairs[g.Player.P]; ok {
 		interactMenu = "[descend]"
 		show = true
+	} else if f, ok := g.Features[g.Player.P]; ok && f == Merchant {
+		interactMenu = "[trade]"
+		show = true
+	} else if f, ok := g.Features[g.Player.P]; ok && !f.Used() {
+		interactMenu = "[use]"
+		show = true
 	}
 	if !show {
 		return ""
@@ -1562,6 +1612,7 @@ type wizardAction int
 const (
 	WizardInfoAction wizardAction = iota
 	WizardToggleMap
+	WizardToggleScent
 )
 
 func (a wizardAction) String() (text string) {
@@ -1570,6 +1621,8 @@ func (a wizardAction) String() (text string) {
 		text = "Info"
 	case WizardToggleMap:
 		text = "toggle see/hide monsters"
+	case WizardToggleScent:
+		text = "toggle see/hide scent map"
 	}
 	return text
 }
@@ -1577,6 +1630,7 @@ func (a wizardAction) String() (text string) {
 var wizardActions = []wizardAction{
 	WizardInfoAction,
 	WizardToggleMap,
+	WizardToggleScent,
 }
 
 func (ui *gameui) HandleWizardAction() error {
@@ -1591,12 +1645,16 @@ func (ui *gameui) HandleWizardAction() error {
 	case WizardToggleMap:
 		g.WizardMap = !g.WizardMap
 		ui.DrawDungeonView(NoFlushMode)
+	case WizardToggleScent:
+		g.WizardScent = !g.WizardScent
+		ui.DrawDungeonView(NoFlushMode)
 	}
 	return nil
 }
 
 func (ui *gameui) Death() {
 	g := ui.g
+	ui.RecordDaily()
 	g.Print("You die... [(x) to continue]")
 	ui.DrawDungeonView(NormalMode)
 	ui.WaitForContinue(-1)
@@ -1611,6 +1669,7 @@ func (ui *gameui) Win() {
 	if err != nil {
 		g.PrintfStyled("Error removing save file: %v", logError, err)
 	}
+	ui.RecordDaily()
 	if g.Wizard {
 		g.Print("You escape by the magic portal! **WIZARD** [(x) to continue]")
 	} else {
@@ -1623,6 +1682,17 @@ func (ui *gameui) Win() {
 	ui.WaitForContinue(-1)
 }
 
+func (ui *gameui) RecordDaily() {
+	g := ui.g
+	if g.Opts.Daily == "" || g.Wizard {
+		return
+	}
+	err := g.RecordDailyScore()
+	if err != nil {
+		g.PrintfStyled("Error recording daily score: %v", logError, err)
+	}
+}
+
 func (ui *gameui) Dump(err error) {
 	g := ui.g
 	ui.Clear()
@@ -1644,6 +1714,7 @@ func (ui *gameui) Quit() bool {
 	ui.DrawDungeonView(NormalMode)
 	quit := ui.PromptConfirmation()
 	if quit {
+		ui.RecordDaily()
 		err := g.RemoveSaveFile()
 		if err != nil {
 			g.PrintfStyled("Error removing save file: %v [press any key to quit]", logError, err)
@@ -1665,6 +1736,9 @@ func (ui *gameui) Wizard() bool {
 
 func (ui *gameui) HandlePlayerTurn(ev event) bool {
 	g := ui.g
+	if len(g.AptitudeChoices) > 0 {
+		ui.ChooseAptitude()
+	}
 getKey:
 	for {
 		var err error